@@ -0,0 +1,32 @@
+// Command atlas stitches a directory of textures into atlas pages and writes
+// the manifest the game loads with -atlas.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/wmattei/minceraft/pkg/atlas"
+)
+
+func main() {
+	defaults := atlas.DefaultOptions()
+	in := flag.String("in", "assets/textures", "directory of PNG textures to stitch")
+	out := flag.String("out", "assets/atlas", "directory the pages and the manifest are written to")
+	name := flag.String("name", "blocks", "base name of the written files")
+	pageSize := flag.Int("page-size", defaults.PageSize, "width and height of a page in pixels")
+	padding := flag.Int("padding", defaults.Padding, "border in pixels kept around every texture")
+	flag.Parse()
+
+	a, err := atlas.Build(os.DirFS(*in), atlas.Options{PageSize: *pageSize, Padding: *padding})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := a.Write(*out, *name); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Packed %d textures into %d pages, %d mipmap levels\n", len(a.Sprites), len(a.Pages), a.MipLevels)
+}
@@ -2,10 +2,11 @@ package main
 
 import (
 	"github.com/go-gl/glfw/v3.3/glfw"
-	"github.com/wmattei/minceraft/pkg/engine"
+	"github.com/wmattei/minceraft/pkg/render"
+	"github.com/wmattei/minceraft/pkg/world"
 )
 
-func SetupControls(window *glfw.Window, camera *engine.PerspectiveCamera) {
+func SetupControls(window *glfw.Window, camera *render.PerspectiveCamera) {
 	var lastX, lastY float64
 	var firstMouse bool = true
 
@@ -34,7 +35,7 @@ func SetupControls(window *glfw.Window, camera *engine.PerspectiveCamera) {
 	window.SetCursorPosCallback(mouseCallback)
 }
 
-func HandleInput(window *glfw.Window, camera *engine.PerspectiveCamera, dt float32) {
+func HandleInput(window *glfw.Window, camera *render.PerspectiveCamera, dt float32) {
 	if window.GetKey(glfw.KeyW) == glfw.Press {
 		camera.ProcessKeyboard("FORWARD", dt)
 	}
@@ -59,3 +60,23 @@ func HandleInput(window *glfw.Window, camera *engine.PerspectiveCamera, dt float
 	}
 
 }
+
+// SetupWorldControls binds the keys toggling world settings: L switches smooth
+// lighting, O occlusion culling and T reloads the resource packs with
+// reloadResources.
+func SetupWorldControls(window *glfw.Window, w *world.World, reloadResources func()) {
+	window.SetKeyCallback(func(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+
+		switch key {
+		case glfw.KeyL:
+			w.SetSmoothLighting(!w.SmoothLighting())
+		case glfw.KeyO:
+			w.SetOcclusionCulling(!w.OcclusionCulling())
+		case glfw.KeyT:
+			reloadResources()
+		}
+	})
+}
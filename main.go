@@ -1,18 +1,23 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"math"
 	"net/http"
 	"os"
 	"runtime/pprof"
+	"strings"
 	"time"
 
-	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	minemath "github.com/wmattei/minceraft/math"
 	"github.com/wmattei/minceraft/pkg/engine"
+	"github.com/wmattei/minceraft/pkg/render"
+	"github.com/wmattei/minceraft/pkg/resources"
+	"github.com/wmattei/minceraft/pkg/world"
 
 	_ "net/http/pprof"
 )
@@ -20,7 +25,20 @@ import (
 const WIDTH = 1200
 const HEIGHT = 780
 
+var exportPath = flag.String("export", "", "write the terrain around the origin to an .obj or .glb file and exit")
+var exportRadius = flag.Int("export-radius", 2, "radius, in chunks, of the exported terrain")
+var shaderDir = flag.String("shader-dir", "", "load the shaders from this directory and reload them when they change, instead of using the built-in ones")
+var atlasPath = flag.String("atlas", "", "load the block textures from an atlas manifest written by cmd/atlas, instead of stitching "+world.TEXTURE_DIR+" at startup")
+var resourcePacks = flag.String("resource-packs", "", "comma separated resource packs, directories or zip files, read before the built-in assets, the first one taking precedence")
+var dayLength = flag.Float64("day-length", world.DEFAULT_DAY_LENGTH, "length of a full day, in seconds, 0 stops the time")
+
 func main() {
+	flag.Parse()
+	if *exportPath != "" {
+		exportTerrain(*exportPath, *exportRadius)
+		return
+	}
+
 	go func() {
 		log.Println(http.ListenAndServe("localhost:6060", nil))
 	}()
@@ -31,17 +49,38 @@ func main() {
 	pprof.StartCPUProfile(f)
 	defer pprof.StopCPUProfile()
 
+	assets := openResourcePacks()
+	defer assets.Close()
+
+	// Packs can override the built-in shaders, as can -shader-dir.
+	packShaders, err := fs.Sub(assets, SHADER_DIR)
+	if err != nil {
+		log.Fatal(err)
+	}
+	shaders := resources.Layers{packShaders, engine.Shaders}
+	if *shaderDir != "" {
+		shaders = append(resources.Layers{os.DirFS(*shaderDir)}, shaders...)
+	}
+
 	window := engine.InitializeWindow(WIDTH, HEIGHT)
-	program := engine.InitOpenGL()
-	gl.UseProgram(program)
+	program := engine.InitOpenGL(shaders)
+	program.Use()
+
+	w := world.NewWorld(8, assets, newGLBackend(), *atlasPath)
+	defer w.Close()
+	w.SetDayLength(*dayLength)
 
-	world := NewWorld(8)
+	sky := engine.NewSky(shaders)
+	defer sky.Delete()
+	shadows := engine.NewShadowMap(shaders)
+	defer shadows.Delete()
+	programs := []*engine.ShaderProgram{program, sky.Program, shadows.Program}
 
 	// return
-	// world := NewSingleChunkWorld()
-	// world := NewSingleBlockWorld()
+	// w := world.NewSingleChunkWorld(assets, newGLBackend(), *atlasPath)
+	// w := world.NewSingleBlockWorld(assets, newGLBackend(), *atlasPath)
 
-	cam := engine.NewPerspectiveCamera(
+	cam := render.NewPerspectiveCamera(
 		[3]float32{0, 89, 0},
 		[3]float32{0, 1, 0},
 		0,
@@ -52,12 +91,27 @@ func main() {
 		1000,
 	)
 
-	frustum := engine.NewFrustum(cam)
-
-	viewLoc := gl.GetUniformLocation(program, gl.Str("view\x00"))
-	projLoc := gl.GetUniformLocation(program, gl.Str("projection\x00"))
+	frustum := render.NewFrustum(cam)
+	var shadowFrustum render.Frustum
+	renderer := engine.NewGLRenderer()
+	createTerrainMaterials(w, program, shadows, cam)
 
 	SetupControls(window, cam)
+	SetupWorldControls(window, w, func() {
+		if err := assets.Reload(); err != nil {
+			log.Println("resource packs:", err)
+			return
+		}
+		if err := w.ReloadTextures(); err != nil {
+			log.Println("textures:", err)
+		}
+		for _, p := range programs {
+			if err := p.Reload(); err != nil {
+				log.Println(err)
+			}
+		}
+		log.Printf("reloaded the resource packs %v", assets.Packs())
+	})
 
 	glfw.SwapInterval(0)
 
@@ -69,10 +123,19 @@ func main() {
 	for !window.ShouldClose() {
 		currentTime := time.Now()
 
-		HandleInput(window, cam, float32(currentTime.Sub(lastTime).Seconds()))
+		dt := currentTime.Sub(lastTime).Seconds()
+		HandleInput(window, cam, float32(dt))
+		w.AdvanceTime(dt)
+		w.AnimateTextures(dt)
+
+		for _, p := range programs {
+			if _, err := p.ReloadIfChanged(); err != nil {
+				log.Println(err)
+			}
+		}
 
-		world.CheckCollisions(cam)
-		world.Update(cam)
+		w.CheckCollisions(cam)
+		w.Update(cam)
 
 		lastTime = currentTime
 		frameCount++
@@ -81,23 +144,54 @@ func main() {
 			fps = frameCount
 			frameCount = 0
 			fpsTime = currentTime
-			window.SetTitle(fmt.Sprintf("FPS: %d", fps))
+			culling := w.CullingStats()
+			window.SetTitle(fmt.Sprintf("FPS: %d, meshes: %d, draw calls: %d, chunks drawn: %d, culled: %d, sections occluded: %d",
+				fps, renderer.Stats().Meshes, renderer.Stats().DrawCalls, culling.ChunksDrawn, culling.ChunksCulled, culling.SectionsOccluded))
 		}
 
-		view := cam.GetViewMatrix()
-		viewFlatten := view.Flatten()
-		projection := cam.GetProjectionMatrix()
-		projectionFlatten := projection.Flatten()
-		frustum.UpdateFrustum(minemath.MultiplyMatrices(projection, view))
+		frustum.UpdateFrustum(minemath.MultiplyMatrices(cam.GetProjectionMatrix(), cam.GetViewMatrix()))
 
-		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-		gl.UseProgram(program)
-		gl.UniformMatrix4fv(viewLoc, 1, false, &viewFlatten[0])
-		gl.UniformMatrix4fv(projLoc, 1, false, &projectionFlatten[0])
-
-		world.Render(program, frustum)
+		renderShadows(w, renderer, shadows, cam, &shadowFrustum)
+		renderSky(w, sky, cam)
+		w.Render(renderer, frustum, cam)
 
 		window.SwapBuffers()
 		glfw.PollEvents()
 	}
 }
+
+// exportTerrain generates the terrain around the origin without opening a
+// window and writes its mesh to path.
+func exportTerrain(path string, radius int) {
+	assets := openResourcePacks()
+	defer assets.Close()
+
+	w := world.NewHeadlessWorld(radius, assets)
+	defer w.Close()
+
+	chunks := w.ChunksInRegion([2]int{-radius, -radius}, [2]int{radius - 1, radius - 1})
+	if err := w.ExportChunks(chunks, path); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("exported %d chunks to %s", len(chunks), path)
+}
+
+// BASE_PACK holds the built-in assets, below every resource pack. Packs lay
+// their files out the same way, under an assets directory.
+const BASE_PACK = "."
+
+// SHADER_DIR is where packs put shaders overriding the built-in ones.
+const SHADER_DIR = "assets/shaders"
+
+func openResourcePacks() *resources.Manager {
+	var packs []string
+	if *resourcePacks != "" {
+		packs = strings.Split(*resourcePacks, ",")
+	}
+
+	assets, err := resources.NewManager(append(packs, BASE_PACK)...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return assets
+}
@@ -71,11 +71,3 @@ func MultiplyMatrices(a, b Mat4) Mat4 {
 	}
 	return result
 }
-
-func CalculateLightIntensity(normal, lightDir Vec3) float32 {
-	intensity := Dot(normal, lightDir)
-	if intensity < 0.4 {
-		intensity = 0.4
-	}
-	return intensity
-}
@@ -33,3 +33,12 @@ func GetTranslationMatrix(x, y, z float32) Mat4 {
 		{0, 0, 0, 1},
 	}
 }
+
+func GetOrthographicProjectionMatrix(left, right, bottom, top, near, far float32) Mat4 {
+	return Mat4{
+		{2 / (right - left), 0, 0, -(right + left) / (right - left)},
+		{0, 2 / (top - bottom), 0, -(top + bottom) / (top - bottom)},
+		{0, 0, -2 / (far - near), -(far + near) / (far - near)},
+		{0, 0, 0, 1},
+	}
+}
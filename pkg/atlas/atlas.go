@@ -0,0 +1,248 @@
+// Package atlas stitches a directory of block textures into atlas pages and
+// describes where every texture ended up in a generated manifest.
+package atlas
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io/fs"
+	"math/bits"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type Options struct {
+	// PageSize is the width and height of every page.
+	PageSize int
+	// Padding is the border, copied from the edges of a sprite, kept around
+	// it so filtering and mipmaps don't bleed the neighbouring sprites in.
+	Padding int
+}
+
+func DefaultOptions() Options {
+	return Options{PageSize: 1024, Padding: 4}
+}
+
+// MipLevels is the number of mipmap levels, the full size one included, that
+// stay free of bleeding with the padding: the padding of the last one is a
+// single texel wide.
+func (o Options) MipLevels() int {
+	if o.Padding <= 0 {
+		return 1
+	}
+	return bits.Len(uint(o.Padding))
+}
+
+// Sprite is the place of a texture in the atlas. X, Y, Width and Height are
+// in pixels, without the padding, and UV holds the same rectangle as
+// u0, v0, u1, v1 texture coordinates.
+type Sprite struct {
+	Page   int        `json:"page"`
+	X      int        `json:"x"`
+	Y      int        `json:"y"`
+	Width  int        `json:"width"`
+	Height int        `json:"height"`
+	UV     [4]float32 `json:"uv"`
+}
+
+type Atlas struct {
+	PageSize  int `json:"pageSize"`
+	MipLevels int `json:"mipLevels"`
+	// PageFiles are the names of the pages in the manifest, relative to it.
+	PageFiles []string `json:"pages"`
+	// Sprites are keyed by the path of their image relative to the scanned
+	// directory, without the extension, like "block/grass_block_top".
+	Sprites map[string]Sprite `json:"sprites"`
+
+	Pages []*image.RGBA `json:"-"`
+}
+
+type source struct {
+	name  string
+	image image.Image
+}
+
+// Build packs every PNG below the root of fsys into as few pages as possible.
+func Build(fsys fs.FS, opts Options) (*Atlas, error) {
+	var sources []source
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.EqualFold(path.Ext(name), ".png") {
+			return err
+		}
+
+		file, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		img, err := png.Decode(file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		sources = append(sources, source{name: strings.TrimSuffix(name, path.Ext(name)), image: img})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pack(sources, opts)
+}
+
+// pack places the sources on shelves, the tallest first. Cells, a sprite and
+// its padding, start and end on multiples of the texel size of the smallest
+// mipmap so each of its texels only covers one sprite.
+func pack(sources []source, opts Options) (*Atlas, error) {
+	sort.Slice(sources, func(i, j int) bool {
+		hi, hj := sources[i].image.Bounds().Dy(), sources[j].image.Bounds().Dy()
+		if hi != hj {
+			return hi > hj
+		}
+		return sources[i].name < sources[j].name
+	})
+
+	a := &Atlas{
+		PageSize:  opts.PageSize,
+		MipLevels: opts.MipLevels(),
+		Sprites:   make(map[string]Sprite, len(sources)),
+	}
+	align := 1 << (a.MipLevels - 1)
+
+	var page *image.RGBA
+	var x, y, shelfHeight int
+	for _, src := range sources {
+		size := src.image.Bounds().Size()
+		cellWidth := alignUp(size.X+2*opts.Padding, align)
+		cellHeight := alignUp(size.Y+2*opts.Padding, align)
+		if cellWidth > opts.PageSize || cellHeight > opts.PageSize {
+			return nil, fmt.Errorf("%s is %v, it doesn't fit on a %d pixel page", src.name, size, opts.PageSize)
+		}
+
+		if page != nil && x+cellWidth > opts.PageSize {
+			x, y, shelfHeight = 0, y+shelfHeight, 0
+		}
+		if page == nil || y+cellHeight > opts.PageSize {
+			page = image.NewRGBA(image.Rect(0, 0, opts.PageSize, opts.PageSize))
+			a.Pages = append(a.Pages, page)
+			x, y, shelfHeight = 0, 0, 0
+		}
+
+		sprite := Sprite{
+			Page:   len(a.Pages) - 1,
+			X:      x + opts.Padding,
+			Y:      y + opts.Padding,
+			Width:  size.X,
+			Height: size.Y,
+		}
+		sprite.UV = [4]float32{
+			float32(sprite.X) / float32(opts.PageSize),
+			float32(sprite.Y) / float32(opts.PageSize),
+			float32(sprite.X+sprite.Width) / float32(opts.PageSize),
+			float32(sprite.Y+sprite.Height) / float32(opts.PageSize),
+		}
+		a.Sprites[src.name] = sprite
+		blit(page, image.Rect(x, y, x+cellWidth, y+cellHeight), sprite, src.image)
+
+		x += cellWidth
+		shelfHeight = max(shelfHeight, cellHeight)
+	}
+
+	return a, nil
+}
+
+// blit draws the image at the place of the sprite and extends its edges over
+// the rest of the cell.
+func blit(page *image.RGBA, cell image.Rectangle, sprite Sprite, img image.Image) {
+	rgba := image.NewRGBA(image.Rect(0, 0, sprite.Width, sprite.Height))
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	for py := cell.Min.Y; py < cell.Max.Y; py++ {
+		sy := clamp(py-sprite.Y, 0, sprite.Height-1)
+		for px := cell.Min.X; px < cell.Max.X; px++ {
+			sx := clamp(px-sprite.X, 0, sprite.Width-1)
+			copy(page.Pix[page.PixOffset(px, py):][:4], rgba.Pix[rgba.PixOffset(sx, sy):][:4])
+		}
+	}
+}
+
+func alignUp(value, align int) int {
+	return (value + align - 1) / align * align
+}
+
+func clamp(value, low, high int) int {
+	return min(max(value, low), high)
+}
+
+// Write saves the pages as name_0.png, name_1.png, ... and the manifest as
+// name.json in dir.
+func (a *Atlas) Write(dir, name string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	a.PageFiles = a.PageFiles[:0]
+	for i, page := range a.Pages {
+		pageFile := fmt.Sprintf("%s_%d.png", name, i)
+		if err := writePNG(filepath.Join(dir, pageFile), page); err != nil {
+			return err
+		}
+		a.PageFiles = append(a.PageFiles, pageFile)
+	}
+
+	manifest, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name+".json"), manifest, 0o644)
+}
+
+func writePNG(name string, img image.Image) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Load reads an atlas written by Write, with its pages.
+func Load(fsys fs.FS, manifest string) (*Atlas, error) {
+	data, err := fs.ReadFile(fsys, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Atlas{}
+	if err := json.Unmarshal(data, a); err != nil {
+		return nil, fmt.Errorf("%s: %w", manifest, err)
+	}
+
+	for _, pageFile := range a.PageFiles {
+		name := path.Join(path.Dir(manifest), pageFile)
+		file, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		img, err := png.Decode(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		page := image.NewRGBA(image.Rect(0, 0, a.PageSize, a.PageSize))
+		draw.Draw(page, page.Bounds(), img, img.Bounds().Min, draw.Src)
+		a.Pages = append(a.Pages, page)
+	}
+
+	return a, nil
+}
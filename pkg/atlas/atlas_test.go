@@ -0,0 +1,108 @@
+package atlas
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func solidPNG(t *testing.T, width, height int, c color.RGBA) *fstest.MapFile {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	img.SetRGBA(0, 0, color.RGBA{0, 0, 0, 255})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return &fstest.MapFile{Data: buf.Bytes()}
+}
+
+func TestBuildPacksWithPadding(t *testing.T) {
+	fsys := fstest.MapFS{
+		"block/stone.png": solidPNG(t, 16, 16, color.RGBA{128, 128, 128, 255}),
+		"block/lava.png":  solidPNG(t, 16, 32, color.RGBA{255, 100, 0, 255}),
+		"block/dirt.png":  solidPNG(t, 16, 16, color.RGBA{120, 80, 40, 255}),
+		"readme.txt":      &fstest.MapFile{Data: []byte("not a texture")},
+	}
+	opts := Options{PageSize: 64, Padding: 4}
+
+	a, err := Build(fsys, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Sprites) != 3 || a.MipLevels != 3 {
+		t.Fatalf("got %d sprites and %d mip levels", len(a.Sprites), a.MipLevels)
+	}
+
+	var cells []image.Rectangle
+	for name, sprite := range a.Sprites {
+		cell := image.Rect(sprite.X, sprite.Y, sprite.X+sprite.Width, sprite.Y+sprite.Height).Inset(-opts.Padding)
+		if !cell.In(image.Rect(0, 0, opts.PageSize, opts.PageSize)) {
+			t.Errorf("%s is outside its page: %v", name, cell)
+		}
+		for _, other := range cells {
+			if cell.Overlaps(other) {
+				t.Errorf("%s overlaps another sprite", name)
+			}
+		}
+		cells = append(cells, cell)
+
+		page := a.Pages[sprite.Page]
+		corner := page.RGBAAt(sprite.X-opts.Padding, sprite.Y-opts.Padding)
+		if corner != (color.RGBA{0, 0, 0, 255}) {
+			t.Errorf("the padding of %s doesn't extend its corner: %v", name, corner)
+		}
+		edge := page.RGBAAt(sprite.X+sprite.Width+opts.Padding-1, sprite.Y+1)
+		if inside := page.RGBAAt(sprite.X+sprite.Width-1, sprite.Y+1); edge != inside {
+			t.Errorf("the padding of %s is %v, its edge %v", name, edge, inside)
+		}
+	}
+
+	if _, err := Build(fsys, Options{PageSize: 16, Padding: 4}); err == nil {
+		t.Error("sprites larger than a page were packed")
+	}
+}
+
+func TestWriteAndLoad(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		fsys[name] = solidPNG(t, 16, 16, color.RGBA{10, 20, 30, 255})
+	}
+	a, err := Build(fsys, Options{PageSize: 32, Padding: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Pages) != 3 {
+		t.Fatalf("got %d pages, want 3", len(a.Pages))
+	}
+
+	dir := t.TempDir()
+	if err := a.Write(dir, "blocks"); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(os.DirFS(dir), "blocks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loaded.Pages) != len(a.Pages) || loaded.MipLevels != a.MipLevels {
+		t.Fatalf("loaded %d pages and %d mip levels", len(loaded.Pages), loaded.MipLevels)
+	}
+	for name, sprite := range a.Sprites {
+		if loaded.Sprites[name] != sprite {
+			t.Errorf("%s was loaded as %+v, want %+v", name, loaded.Sprites[name], sprite)
+		}
+	}
+	if !bytes.Equal(loaded.Pages[1].Pix, a.Pages[1].Pix) {
+		t.Error("the loaded page differs from the written one")
+	}
+}
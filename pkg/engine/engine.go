@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"io/fs"
 	"log"
 	"runtime"
 
@@ -44,23 +45,7 @@ func InitializeWindow(width, height int) *glfw.Window {
 	return window
 }
 
-func InitOpenGL() uint32 {
-	program := gl.CreateProgram()
-	initShaders(program)
-	gl.LinkProgram(program)
-
-	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := make([]byte, logLength)
-		gl.GetProgramInfoLog(program, logLength, nil, &log[0])
-		panic("failed to link program:" + string(log))
-	}
-
-	destroyShaders()
-
-	return program
+// InitOpenGL loads the chunk program from the shader files.
+func InitOpenGL(shaders fs.FS) *ShaderProgram {
+	return MustLoadShaderProgram(shaders, "chunk.vert", "chunk.frag")
 }
@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	minemath "github.com/wmattei/minceraft/math"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+const (
+	ORIGINS_UNIT = 2
+	// originSize is the size of the origin of a block, an RGBA32F texel.
+	originSize = 16
+)
+
+// GeometryPool stores many meshes in a few shared buffers behind a single
+// vertex array, so they can be drawn together with one multi-draw call. The
+// origin of every mesh is stored per block and read by the vertex shader
+// from a buffer texture, see Bindings.
+type GeometryPool struct {
+	VAO uint32
+
+	vertexSize     int
+	setup          func()
+	vbo, ebo       uint32
+	origins        uint32
+	originsTexture uint32
+
+	vertexBlocks *render.Allocator
+	indices      *render.Allocator
+}
+
+// NewGeometryPool creates a pool of vertices of vertexSize bytes. setup
+// describes their attributes, it is called with the vertex buffer bound.
+func NewGeometryPool(vertexSize int, setup func()) *GeometryPool {
+	p := &GeometryPool{
+		vertexSize:   vertexSize,
+		setup:        setup,
+		vertexBlocks: render.NewAllocator(0),
+		indices:      render.NewAllocator(0),
+	}
+	gl.GenVertexArrays(1, &p.VAO)
+	gl.GenTextures(1, &p.originsTexture)
+	p.resize(render.INITIAL_VERTEX_BLOCKS, render.INITIAL_POOL_INDICES)
+	return p
+}
+
+// Upload stores a mesh whose vertices are placed relative to origin. The pool
+// grows when it is full.
+func (p *GeometryPool) Upload(vertices []uint32, indices []uint32, origin minemath.Vec3) *render.Allocation {
+	vertexCount := len(vertices) * 4 / p.vertexSize
+	blocks := (vertexCount + render.VERTEX_BLOCK - 1) / render.VERTEX_BLOCK
+
+	firstBlock, ok := p.vertexBlocks.Alloc(blocks)
+	for !ok {
+		p.resize(p.vertexBlocks.Size*2, p.indices.Size)
+		firstBlock, ok = p.vertexBlocks.Alloc(blocks)
+	}
+	firstIndex, ok := p.indices.Alloc(len(indices))
+	for !ok {
+		p.resize(p.vertexBlocks.Size, p.indices.Size*2)
+		firstIndex, ok = p.indices.Alloc(len(indices))
+	}
+
+	gl.BindBuffer(gl.COPY_WRITE_BUFFER, p.vbo)
+	gl.BufferSubData(gl.COPY_WRITE_BUFFER, firstBlock*render.VERTEX_BLOCK*p.vertexSize, len(vertices)*4, gl.Ptr(vertices))
+	gl.BindBuffer(gl.COPY_WRITE_BUFFER, p.ebo)
+	gl.BufferSubData(gl.COPY_WRITE_BUFFER, firstIndex*4, len(indices)*4, gl.Ptr(indices))
+
+	origins := make([]float32, 0, blocks*4)
+	for i := 0; i < blocks; i++ {
+		origins = append(origins, origin[0], origin[1], origin[2], 0)
+	}
+	gl.BindBuffer(gl.COPY_WRITE_BUFFER, p.origins)
+	gl.BufferSubData(gl.COPY_WRITE_BUFFER, firstBlock*originSize, blocks*originSize, gl.Ptr(origins))
+	gl.BindBuffer(gl.COPY_WRITE_BUFFER, 0)
+
+	return &render.Allocation{
+		VAO:        p.VAO,
+		FirstBlock: firstBlock,
+		Blocks:     blocks,
+		FirstIndex: firstIndex,
+		IndexCount: len(indices),
+	}
+}
+
+// Free gives the space of a mesh back to the pool.
+func (p *GeometryPool) Free(a *render.Allocation) {
+	p.vertexBlocks.Free(a.FirstBlock, a.Blocks)
+	p.indices.Free(a.FirstIndex, a.IndexCount)
+}
+
+// resize moves the buffers to larger ones, keeping their contents.
+func (p *GeometryPool) resize(vertexBlocks, indices int) {
+	p.vbo = resizeBuffer(p.vbo, p.vertexBlocks.Size*render.VERTEX_BLOCK*p.vertexSize, vertexBlocks*render.VERTEX_BLOCK*p.vertexSize)
+	p.origins = resizeBuffer(p.origins, p.vertexBlocks.Size*originSize, vertexBlocks*originSize)
+	p.ebo = resizeBuffer(p.ebo, p.indices.Size*4, indices*4)
+	p.vertexBlocks.Grow(vertexBlocks)
+	p.indices.Grow(indices)
+
+	gl.BindVertexArray(p.VAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.vbo)
+	p.setup()
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, p.ebo)
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	gl.BindTexture(gl.TEXTURE_BUFFER, p.originsTexture)
+	gl.TexBuffer(gl.TEXTURE_BUFFER, gl.RGBA32F, p.origins)
+	gl.BindTexture(gl.TEXTURE_BUFFER, 0)
+}
+
+func resizeBuffer(buffer uint32, size, newSize int) uint32 {
+	var resized uint32
+	gl.GenBuffers(1, &resized)
+	gl.BindBuffer(gl.COPY_WRITE_BUFFER, resized)
+	gl.BufferData(gl.COPY_WRITE_BUFFER, newSize, nil, gl.DYNAMIC_DRAW)
+
+	if buffer != 0 {
+		gl.BindBuffer(gl.COPY_READ_BUFFER, buffer)
+		gl.CopyBufferSubData(gl.COPY_READ_BUFFER, gl.COPY_WRITE_BUFFER, 0, 0, size)
+		gl.BindBuffer(gl.COPY_READ_BUFFER, 0)
+		gl.DeleteBuffers(1, &buffer)
+	}
+	gl.BindBuffer(gl.COPY_WRITE_BUFFER, 0)
+	return resized
+}
+
+// Bindings is the buffer texture of the mesh origins, for the materials
+// drawing from the pool.
+func (p *GeometryPool) Bindings() []render.TextureBinding {
+	return []render.TextureBinding{{Unit: ORIGINS_UNIT, Target: gl.TEXTURE_BUFFER, Texture: p.originsTexture, Sampler: "chunkOrigins"}}
+}
+
+func (p *GeometryPool) Delete() {
+	gl.DeleteVertexArrays(1, &p.VAO)
+	gl.DeleteBuffers(1, &p.vbo)
+	gl.DeleteBuffers(1, &p.ebo)
+	gl.DeleteBuffers(1, &p.origins)
+	gl.DeleteTextures(1, &p.originsTexture)
+}
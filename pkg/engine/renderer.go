@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+// MAX_TEXTURE_UNITS is the number of texture units RenderState tracks, the
+// minimum OpenGL 4.1 guarantees to fragment shaders.
+const MAX_TEXTURE_UNITS = 16
+
+type textureState struct {
+	target  uint32
+	texture uint32
+}
+
+// RenderState remembers the GL state set through it to skip redundant
+// changes. Whatever changes the same state directly must call Reset before
+// it is used again.
+type RenderState struct {
+	program  uint32
+	vao      uint32
+	textures [MAX_TEXTURE_UNITS]textureState
+	active   uint32
+
+	Changes int
+	Skipped int
+}
+
+// unknownState is never a GL name, so the first change after a reset is
+// always issued.
+const unknownState = ^uint32(0)
+
+// Reset forgets the tracked state, the next changes are all issued.
+func (s *RenderState) Reset() {
+	s.program, s.vao, s.active = unknownState, unknownState, unknownState
+	for i := range s.textures {
+		s.textures[i] = textureState{unknownState, unknownState}
+	}
+}
+
+func (s *RenderState) UseProgram(program uint32) {
+	if s.program == program {
+		s.Skipped++
+		return
+	}
+	gl.UseProgram(program)
+	s.program = program
+	s.Changes++
+}
+
+func (s *RenderState) BindVertexArray(vao uint32) {
+	if s.vao == vao {
+		s.Skipped++
+		return
+	}
+	gl.BindVertexArray(vao)
+	s.vao = vao
+	s.Changes++
+}
+
+func (s *RenderState) BindTexture(unit, target, texture uint32) {
+	if unit < MAX_TEXTURE_UNITS && s.textures[unit] == (textureState{target, texture}) {
+		s.Skipped++
+		return
+	}
+	if s.active != unit {
+		gl.ActiveTexture(gl.TEXTURE0 + unit)
+		s.active = unit
+	}
+	gl.BindTexture(target, texture)
+	if unit < MAX_TEXTURE_UNITS {
+		s.textures[unit] = textureState{target, texture}
+	}
+	s.Changes++
+}
+
+// GLRenderer queues draw calls and issues them grouped by material, so each
+// material is bound once per flush. Calls sharing a material and a vertex
+// array are issued with a single glMultiDrawElementsBaseVertex.
+type GLRenderer struct {
+	State RenderState
+	stats render.RenderStats
+
+	calls []render.DrawCall
+	// samplersSet is the program the sampler uniforms of a material were
+	// last set on, they are set again when it is rebuilt.
+	samplersSet map[*render.Material]uint32
+
+	// The arguments of the current batch, kept to be reused.
+	counts       []int32
+	offsets      []unsafe.Pointer
+	baseVertices []int32
+}
+
+func NewGLRenderer() *GLRenderer {
+	r := &GLRenderer{samplersSet: make(map[*render.Material]uint32)}
+	r.State.Reset()
+	return r
+}
+
+func (r *GLRenderer) Submit(call render.DrawCall) {
+	r.calls = append(r.calls, call)
+}
+
+// Flush issues the queued draw calls. The state tracking starts over, since
+// GL may have been used directly since the last flush.
+func (r *GLRenderer) Flush() {
+	r.State.Reset()
+	r.State.Changes, r.State.Skipped = 0, 0
+	r.stats = render.RenderStats{}
+	render.SortDrawCalls(r.calls)
+
+	var material *render.Material
+	render.Batches(r.calls, func(batch []render.DrawCall) {
+		if batch[0].Material != material {
+			material = batch[0].Material
+			r.bindMaterial(material)
+			r.stats.Materials++
+		}
+		r.State.BindVertexArray(batch[0].VAO)
+		r.draw(batch)
+	})
+
+	r.State.BindVertexArray(0)
+	r.calls = r.calls[:0]
+	r.stats.StateChanges, r.stats.SkippedChanges = r.State.Changes, r.State.Skipped
+}
+
+func (r *GLRenderer) Stats() render.RenderStats {
+	return r.stats
+}
+
+func (r *GLRenderer) draw(batch []render.DrawCall) {
+	r.stats.Meshes += len(batch)
+	r.stats.DrawCalls++
+
+	if len(batch) == 1 {
+		call := batch[0]
+		gl.DrawElementsBaseVertex(gl.TRIANGLES, call.IndexCount, gl.UNSIGNED_INT, gl.PtrOffset(int(call.FirstIndex)*4), call.BaseVertex)
+		return
+	}
+
+	r.counts, r.offsets, r.baseVertices = r.counts[:0], r.offsets[:0], r.baseVertices[:0]
+	for _, call := range batch {
+		r.counts = append(r.counts, call.IndexCount)
+		r.offsets = append(r.offsets, gl.PtrOffset(int(call.FirstIndex)*4))
+		r.baseVertices = append(r.baseVertices, call.BaseVertex)
+	}
+	gl.MultiDrawElementsBaseVertex(gl.TRIANGLES, &r.counts[0], gl.UNSIGNED_INT, &r.offsets[0], int32(len(batch)), &r.baseVertices[0])
+}
+
+func (r *GLRenderer) bindMaterial(m *render.Material) {
+	id := m.Program.ProgramID()
+	r.State.UseProgram(id)
+
+	setSamplers := r.samplersSet[m] != id
+	for _, texture := range m.Textures {
+		r.State.BindTexture(texture.Unit, texture.Target, texture.Texture)
+		if setSamplers {
+			gl.Uniform1i(m.Program.Uniform(texture.Sampler), int32(texture.Unit))
+		}
+	}
+	r.samplersSet[m] = id
+
+	if m.Bind != nil {
+		m.Bind(m.Program)
+	}
+}
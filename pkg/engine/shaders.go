@@ -1,85 +1,257 @@
 package engine
 
 import (
+	"bufio"
+	"embed"
 	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 )
 
-type Shader uint32
-
-var SIMPLE_VERTEX_SHADER Shader
-var SIMPLE_FRAGMENT_SHADER Shader
-
-const simpleVertexShdr = `
-    #version 410 core
-
-    layout(location = 0) in vec3 inPosition;
-    layout(location = 1) in vec4 inColor;
-    layout(location = 2) in vec2 inTexCoord;
-    layout(location = 3) in float inTexIndex;
-
-    uniform mat4 model;
-    uniform mat4 view;
-    uniform mat4 projection;
-
-    out vec4 color;
-    out vec2 texCoord;
-    out float texIndex;
-
-    void main() {
-        color = inColor;
-        texCoord = inTexCoord;
-        texIndex = inTexIndex;
-        gl_Position = projection * view * model * vec4(inPosition, 1.0);
-    }
-` + "\x00"
-
-const simpleFragmentShdr = `
-    #version 410
-
-    in vec4 color;
-    in vec2 texCoord;
-    in float texIndex;
-
-    out vec4 frag_color;
-
-    uniform sampler2D textures[6]; // Adjust size as needed
-
-    void main() {
-        int idx = int(texIndex);
-        vec4 texColor = texture(textures[idx], texCoord);
-        
-        // If color alpha is 0.0, just use the texture color; otherwise, multiply by color
-        if (color.a == 0.0) {
-            frag_color = texColor;
-        } else {
-            frag_color = texColor * color;
-        }
-    }
-` + "\x00"
-
-func initShaders(program uint32) {
-	vertexShader, err := compileShader(simpleVertexShdr, gl.VERTEX_SHADER)
+//go:embed shaders
+var embeddedShaders embed.FS
+
+// Shaders holds the shader sources built into the binary. Programs can be
+// loaded from a directory instead, with os.DirFS, to hot reload them.
+var Shaders fs.FS
+
+func init() {
+	var err error
+	Shaders, err = fs.Sub(embeddedShaders, "shaders")
 	if err != nil {
 		panic(err)
 	}
-	fragShader, err := compileShader(simpleFragmentShdr, gl.FRAGMENT_SHADER)
+}
+
+// HOT_RELOAD_INTERVAL is how often ReloadIfChanged looks at the source files.
+const HOT_RELOAD_INTERVAL = 500 * time.Millisecond
+
+// ShaderError is a shader that failed to compile or link, with the lines of
+// its log pointing at the source files.
+type ShaderError struct {
+	Path string
+	Log  string
+}
+
+func (e *ShaderError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Log)
+}
+
+// ShaderProgram is a program built from a vertex and a fragment shader file.
+// Sources may #include other files, relative to the including one.
+type ShaderProgram struct {
+	ID uint32
+
+	fsys         fs.FS
+	vertexPath   string
+	fragmentPath string
+
+	uniforms  map[string]int32
+	files     map[string]time.Time
+	lastCheck time.Time
+}
+
+func LoadShaderProgram(fsys fs.FS, vertexPath, fragmentPath string) (*ShaderProgram, error) {
+	p := &ShaderProgram{fsys: fsys, vertexPath: vertexPath, fragmentPath: fragmentPath}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// MustLoadShaderProgram is LoadShaderProgram for shaders that are expected to
+// build, such as the embedded ones.
+func MustLoadShaderProgram(fsys fs.FS, vertexPath, fragmentPath string) *ShaderProgram {
+	p, err := LoadShaderProgram(fsys, vertexPath, fragmentPath)
 	if err != nil {
 		panic(err)
 	}
+	return p
+}
+
+// Reload rebuilds the program from its files. The previous program is kept
+// when the new one fails to build.
+func (p *ShaderProgram) Reload() error {
+	files := make(map[string]time.Time)
+
+	vertexShader, err := p.compile(p.vertexPath, gl.VERTEX_SHADER, files)
+	if err != nil {
+		return err
+	}
+	defer gl.DeleteShader(vertexShader)
+	fragShader, err := p.compile(p.fragmentPath, gl.FRAGMENT_SHADER, files)
+	if err != nil {
+		return err
+	}
+	defer gl.DeleteShader(fragShader)
+
+	program := gl.CreateProgram()
+	gl.AttachShader(program, vertexShader)
+	gl.AttachShader(program, fragShader)
+	if log, ok := linkProgram(program); !ok {
+		gl.DeleteProgram(program)
+		return &ShaderError{Path: p.vertexPath + ", " + p.fragmentPath, Log: log}
+	}
+
+	if p.ID != 0 {
+		gl.DeleteProgram(p.ID)
+	}
+	p.ID = program
+	p.uniforms = make(map[string]int32)
+	p.files = files
+	p.lastCheck = time.Now()
+	return nil
+}
+
+func (p *ShaderProgram) compile(file string, shaderType uint32, files map[string]time.Time) (uint32, error) {
+	source, sources, err := preprocessShader(p.fsys, file)
+	if err != nil {
+		return 0, err
+	}
+	for _, name := range sources {
+		files[name] = modTime(p.fsys, name)
+	}
+
+	shader, log, ok := compileShader(source, shaderType)
+	if !ok {
+		return 0, &ShaderError{Path: file, Log: mapShaderLog(log, sources)}
+	}
+	return shader, nil
+}
+
+// ReloadIfChanged reloads the program when one of its files was modified,
+// looking at most once per HOT_RELOAD_INTERVAL. Embedded files never change.
+func (p *ShaderProgram) ReloadIfChanged() (bool, error) {
+	if time.Since(p.lastCheck) < HOT_RELOAD_INTERVAL {
+		return false, nil
+	}
+	p.lastCheck = time.Now()
 
-	SIMPLE_VERTEX_SHADER = Shader(vertexShader)
-	SIMPLE_FRAGMENT_SHADER = Shader(fragShader)
+	for name, loaded := range p.files {
+		if modTime(p.fsys, name).After(loaded) {
+			return true, p.Reload()
+		}
+	}
+	return false, nil
+}
+
+func modTime(fsys fs.FS, name string) time.Time {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func (p *ShaderProgram) Use() {
+	gl.UseProgram(p.ID)
+}
+
+// ProgramID is the GL name of the program, it changes when it is reloaded.
+func (p *ShaderProgram) ProgramID() uint32 {
+	return p.ID
+}
+
+// Uniform returns the location of the uniform, looking it up only once per
+// build of the program.
+func (p *ShaderProgram) Uniform(name string) int32 {
+	location, ok := p.uniforms[name]
+	if !ok {
+		location = gl.GetUniformLocation(p.ID, gl.Str(name+"\x00"))
+		p.uniforms[name] = location
+	}
+	return location
+}
+
+func (p *ShaderProgram) Delete() {
+	gl.DeleteProgram(p.ID)
+	p.ID = 0
+}
+
+var includeDirective = regexp.MustCompile(`^\s*#include\s+"([^"]+)"\s*$`)
+
+// preprocessShader expands the #include directives of a source file. Every
+// file gets a number, its position in the returned list, and #line directives
+// keep the compiler log pointing at the lines of the original files.
+func preprocessShader(fsys fs.FS, file string) (string, []string, error) {
+	var out strings.Builder
+	var files []string
+	if err := expandShader(fsys, path.Clean(file), &out, &files, nil); err != nil {
+		return "", nil, err
+	}
+	return out.String(), files, nil
+}
 
-	gl.AttachShader(program, uint32(SIMPLE_VERTEX_SHADER))
-	gl.AttachShader(program, uint32(SIMPLE_FRAGMENT_SHADER))
+func expandShader(fsys fs.FS, file string, out *strings.Builder, files *[]string, stack []string) error {
+	for _, parent := range stack {
+		if parent == file {
+			return fmt.Errorf("circular #include of %s", file)
+		}
+	}
+
+	source, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return err
+	}
+	index := len(*files)
+	*files = append(*files, file)
+	stack = append(stack, file)
+
+	scanner := bufio.NewScanner(strings.NewReader(string(source)))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+
+		if match := includeDirective.FindStringSubmatch(text); match != nil {
+			fmt.Fprintf(out, "#line 1 %d\n", len(*files))
+			if err := expandShader(fsys, path.Join(path.Dir(file), match[1]), out, files, stack); err != nil {
+				return fmt.Errorf("%s:%d: %w", file, line, err)
+			}
+			fmt.Fprintf(out, "#line %d %d\n", line+1, index)
+			continue
+		}
+
+		out.WriteString(text)
+		out.WriteByte('\n')
+		// #version has to come first, the numbering starts right after it.
+		if strings.HasPrefix(strings.TrimSpace(text), "#version") {
+			fmt.Fprintf(out, "#line %d %d\n", line+1, index)
+		}
+	}
+	return scanner.Err()
 }
 
-func compileShader(source string, shaderType uint32) (uint32, error) {
+// shaderLogLocation matches the "source:line" prefixes drivers put in their
+// logs, such as "0:12(3):" or "ERROR: 0:12:".
+var shaderLogLocation = regexp.MustCompile(`(\d+):(\d+)`)
+
+// mapShaderLog replaces the source numbers of a compiler log with the names
+// of the files they stand for.
+func mapShaderLog(log string, files []string) string {
+	lines := strings.Split(strings.TrimRight(log, "\x00\n"), "\n")
+	for i, line := range lines {
+		match := shaderLogLocation.FindStringSubmatchIndex(line)
+		if match == nil {
+			continue
+		}
+		index, _ := strconv.Atoi(line[match[2]:match[3]])
+		if index >= len(files) {
+			continue
+		}
+		lines[i] = line[:match[0]] + files[index] + ":" + line[match[4]:match[5]] + line[match[1]:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func compileShader(source string, shaderType uint32) (uint32, string, bool) {
 	shader := gl.CreateShader(shaderType)
 
-	csources, free := gl.Strs(source)
+	csources, free := gl.Strs(source + "\x00")
 	gl.ShaderSource(shader, 1, csources, nil)
 	free()
 	gl.CompileShader(shader)
@@ -90,16 +262,28 @@ func compileShader(source string, shaderType uint32) (uint32, error) {
 		var logLength int32
 		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
 
-		log := make([]byte, logLength)
+		log := make([]byte, logLength+1)
 		gl.GetShaderInfoLog(shader, logLength, nil, &log[0])
+		gl.DeleteShader(shader)
 
-		return 0, fmt.Errorf("failed to compile %v: %v", source, string(log))
+		return 0, string(log), false
 	}
 
-	return shader, nil
+	return shader, "", true
 }
 
-func destroyShaders() {
-	gl.DeleteShader(uint32(SIMPLE_VERTEX_SHADER))
-	gl.DeleteShader(uint32(SIMPLE_FRAGMENT_SHADER))
+func linkProgram(program uint32) (string, bool) {
+	gl.LinkProgram(program)
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := make([]byte, logLength+1)
+		gl.GetProgramInfoLog(program, logLength, nil, &log[0])
+		return strings.TrimRight(string(log), "\x00"), false
+	}
+	return "", true
 }
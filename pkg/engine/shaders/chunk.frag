@@ -0,0 +1,100 @@
+#version 410 core
+
+in vec3 albedo;
+in float skyLight;
+in vec3 blockLight;
+in vec3 worldPosition;
+in float viewDepth;
+in float viewDistance;
+flat in vec3 worldNormal;
+flat in int normalIndex;
+in vec2 texCoord;
+in vec2 nextTexCoord;
+flat in float texPage;
+flat in float frameBlend;
+
+out vec4 frag_color;
+
+// The atlas pages, one per layer.
+uniform sampler2DArray blockTextures;
+
+// Lighting model, see lighting_settings.go. The first directional light is
+// the sun or the moon and is the only one casting shadows.
+uniform vec3 ambientLight;
+uniform vec3 hemisphereSky;
+uniform vec3 hemisphereGround;
+uniform int lightCount;
+uniform vec3 lightDirections[4];
+uniform vec3 lightColors[4];
+uniform float faceShading[6];
+
+// Fog, see fog.go. Modes are none, linear and squared exponential.
+uniform int fogMode;
+uniform float fogStart;
+uniform float fogEnd;
+uniform float fogDensity;
+uniform vec3 fogColor;
+
+// Shadow cascades, see shadow.go.
+uniform sampler2DArrayShadow shadowMap;
+uniform mat4 lightViewProjections[3];
+uniform float cascadeSplits[3];
+uniform float shadowNormalOffsets[3];
+
+// fogFactor returns how much of the fragment the fog hides.
+float fogFactor() {
+    if (fogMode == 1) {
+        return clamp((viewDistance - fogStart) / (fogEnd - fogStart), 0.0, 1.0);
+    }
+    if (fogMode == 2) {
+        float density = viewDistance * fogDensity;
+        return 1.0 - exp(-density * density);
+    }
+    return 0.0;
+}
+
+// shadow returns how much of the sun reaches the fragment, filtering
+// a 3x3 block of shadow map texels.
+float shadow() {
+    for (int cascade = 0; cascade < 3; cascade++) {
+        if (viewDepth >= cascadeSplits[cascade]) {
+            continue;
+        }
+
+        vec3 position = worldPosition + worldNormal * shadowNormalOffsets[cascade];
+        vec4 projected = lightViewProjections[cascade] * vec4(position, 1.0);
+        vec3 coords = projected.xyz / projected.w * 0.5 + 0.5;
+
+        vec2 texel = 1.0 / vec2(textureSize(shadowMap, 0).xy);
+        float lit = 0.0;
+        for (int x = -1; x <= 1; x++) {
+            for (int y = -1; y <= 1; y++) {
+                lit += texture(shadowMap, vec4(coords.xy + vec2(x, y) * texel, cascade, coords.z));
+            }
+        }
+        return lit / 9.0;
+    }
+    return 1.0;
+}
+
+void main() {
+    vec3 sky = ambientLight + mix(hemisphereGround, hemisphereSky, worldNormal.y * 0.5 + 0.5);
+    for (int i = 0; i < lightCount; i++) {
+        float diffuse = max(dot(worldNormal, lightDirections[i]), 0.0);
+        if (i == 0 && diffuse > 0.0) {
+            diffuse *= shadow();
+        }
+        sky += lightColors[i] * diffuse;
+    }
+    sky *= faceShading[normalIndex];
+
+    // Block light isn't shaded by the sun, it lights interiors and nights.
+    vec3 light = max(skyLight * sky, blockLight);
+
+    vec4 texColor = texture(blockTextures, vec3(texCoord, texPage));
+    if (frameBlend > 0.0) {
+        texColor = mix(texColor, texture(blockTextures, vec3(nextTexCoord, texPage)), frameBlend);
+    }
+    vec3 color = texColor.rgb * albedo * light;
+    frag_color = vec4(mix(color, fogColor, fogFactor()), texColor.a);
+}
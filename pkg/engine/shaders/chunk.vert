@@ -0,0 +1,73 @@
+#version 410 core
+
+#include "packed_vertex.glsl"
+
+uniform mat4 view;
+uniform mat4 projection;
+
+uniform float skyBrightness;
+uniform vec3 highlightColor;
+// One column per sprite: its rectangle in the page, its tint and page, then
+// the corner of its next animation frame and the blend towards it.
+uniform sampler2D sprites;
+
+const vec3 normals[6] = vec3[6](
+    vec3(1.0, 0.0, 0.0),
+    vec3(-1.0, 0.0, 0.0),
+    vec3(0.0, 1.0, 0.0),
+    vec3(0.0, -1.0, 0.0),
+    vec3(0.0, 0.0, 1.0),
+    vec3(0.0, 0.0, -1.0)
+);
+const float aoFactors[4] = float[4](0.45, 0.65, 0.82, 1.0);
+
+out vec3 albedo;
+out float skyLight;
+out vec3 blockLight;
+out vec3 worldPosition;
+out float viewDepth;
+out float viewDistance;
+flat out vec3 worldNormal;
+flat out int normalIndex;
+out vec2 texCoord;
+out vec2 nextTexCoord;
+flat out float texPage;
+flat out float frameBlend;
+
+void main() {
+    uint data = inVertex.x;
+    uint attributes = inVertex.y;
+
+    vec3 position = unpackPosition(data);
+    normalIndex = unpackNormal(data);
+    vec3 normal = normals[normalIndex];
+
+    int sprite = int(attributes & 255u);
+    vec4 spriteRect = texelFetch(sprites, ivec2(sprite, 0), 0);
+    vec4 spriteTint = texelFetch(sprites, ivec2(sprite, 1), 0);
+    vec4 spriteNext = texelFetch(sprites, ivec2(sprite, 2), 0);
+    vec2 tileCoord = unpackTexCoord(data) * spriteRect.zw;
+    texCoord = spriteRect.xy + tileCoord;
+    nextTexCoord = spriteNext.xy + tileCoord;
+    texPage = spriteTint.a;
+    frameBlend = spriteNext.z;
+
+    float ao = aoFactors[(attributes >> 8) & 3u];
+    skyLight = pow(0.8, 15.0 - float((attributes >> 10) & 15u)) * skyBrightness;
+    vec3 blockLevels = vec3((attributes >> 15) & 15u, (attributes >> 19) & 15u, (attributes >> 23) & 15u);
+    blockLight = pow(vec3(0.8), vec3(15.0) - blockLevels);
+
+    vec3 tint = spriteTint.rgb;
+    if (((attributes >> 14) & 1u) == 1u) {
+        tint = highlightColor;
+    }
+    albedo = tint * ao;
+
+    vec4 world = vec4(chunkOrigin() + position, 1.0);
+    vec4 eye = view * world;
+    worldPosition = world.xyz;
+    worldNormal = normal;
+    viewDepth = -eye.z;
+    viewDistance = length(eye.xyz);
+    gl_Position = projection * eye;
+}
@@ -0,0 +1,24 @@
+// Packed chunk vertex, see vertex.go for the bit layout.
+layout(location = 0) in uvec2 inVertex;
+
+vec3 unpackPosition(uint data) {
+    return vec3(data & 31u, (data >> 5) & 511u, (data >> 14) & 31u);
+}
+
+int unpackNormal(uint data) {
+    return int((data >> 19) & 7u);
+}
+
+vec2 unpackTexCoord(uint data) {
+    return vec2((data >> 22) & 1u, (data >> 23) & 1u);
+}
+
+// Meshes drawn from a geometry pool own whole blocks of VERTEX_BLOCK
+// vertices, the origin of each block is in chunkOrigins. See
+// geometry_pool.go.
+const int VERTEX_BLOCK = 256;
+uniform samplerBuffer chunkOrigins;
+
+vec3 chunkOrigin() {
+    return texelFetch(chunkOrigins, gl_VertexID / VERTEX_BLOCK).xyz;
+}
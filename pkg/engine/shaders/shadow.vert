@@ -0,0 +1,10 @@
+#version 410 core
+
+#include "packed_vertex.glsl"
+
+uniform mat4 lightViewProjection;
+
+void main() {
+    vec3 position = unpackPosition(inVertex.x);
+    gl_Position = lightViewProjection * vec4(chunkOrigin() + position, 1.0);
+}
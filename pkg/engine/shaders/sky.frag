@@ -0,0 +1,24 @@
+#version 410 core
+
+in vec2 screen;
+
+uniform vec3 cameraFront;
+uniform vec3 cameraRight;
+uniform vec3 cameraUp;
+uniform vec2 viewScale;
+
+uniform vec3 zenithColor;
+uniform vec3 horizonColor;
+uniform vec3 sunDirection;
+
+out vec4 frag_color;
+
+void main() {
+    vec3 ray = normalize(cameraFront + screen.x * viewScale.x * cameraRight + screen.y * viewScale.y * cameraUp);
+
+    vec3 color = mix(horizonColor, zenithColor, sqrt(clamp(ray.y, 0.0, 1.0)));
+    color += vec3(1.0, 0.9, 0.7) * pow(max(dot(ray, sunDirection), 0.0), 512.0);
+    color += vec3(0.6, 0.65, 0.8) * pow(max(dot(ray, -sunDirection), 0.0), 1024.0);
+
+    frag_color = vec4(color, 1.0);
+}
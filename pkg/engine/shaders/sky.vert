@@ -0,0 +1,9 @@
+#version 410 core
+
+out vec2 screen;
+
+// A single triangle covering the screen, generated from the vertex id.
+void main() {
+    screen = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2) * 2.0 - 1.0;
+    gl_Position = vec4(screen, 0.0, 1.0);
+}
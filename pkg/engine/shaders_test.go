@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPreprocessShaderIncludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.vert":       {Data: []byte("#version 410 core\n#include \"lib/common.glsl\"\nvoid main() {}\n")},
+		"lib/common.glsl": {Data: []byte("#include \"math.glsl\"\nfloat half(float x) { return x / 2.0; }\n")},
+		"lib/math.glsl":   {Data: []byte("const float PI = 3.14159;\n")},
+		"cycle.vert":      {Data: []byte("#include \"cycle.vert\"\n")},
+		"broken.vert":     {Data: []byte("#include \"missing.glsl\"\n")},
+	}
+
+	source, files, err := preprocessShader(fsys, "main.vert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"main.vert", "lib/common.glsl", "lib/math.glsl"}; strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+	want := "#version 410 core\n#line 2 0\n" +
+		"#line 1 1\n#line 1 2\nconst float PI = 3.14159;\n#line 2 1\nfloat half(float x) { return x / 2.0; }\n" +
+		"#line 3 0\nvoid main() {}\n"
+	if source != want {
+		t.Errorf("source =\n%s\nwant\n%s", source, want)
+	}
+
+	if _, _, err := preprocessShader(fsys, "cycle.vert"); err == nil || !strings.Contains(err.Error(), "circular") {
+		t.Errorf("expected a circular include error, got %v", err)
+	}
+	if _, _, err := preprocessShader(fsys, "broken.vert"); err == nil || !strings.Contains(err.Error(), "broken.vert:1") {
+		t.Errorf("expected the error to point at the include, got %v", err)
+	}
+}
+
+func TestMapShaderLog(t *testing.T) {
+	files := []string{"chunk.frag", "packed_vertex.glsl"}
+	log := "0:12(3): error: undeclared identifier\nERROR: 1:4: 'x' : syntax error\nlink failed\x00"
+
+	want := "chunk.frag:12(3): error: undeclared identifier\nERROR: packed_vertex.glsl:4: 'x' : syntax error\nlink failed"
+	if got := mapShaderLog(log, files); got != want {
+		t.Errorf("mapShaderLog =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEmbeddedShadersPreprocess(t *testing.T) {
+	for _, name := range []string{"chunk.vert", "chunk.frag", "shadow.vert", "shadow.frag", "sky.vert", "sky.frag"} {
+		if _, _, err := preprocessShader(Shaders, name); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
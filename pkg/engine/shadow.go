@@ -0,0 +1,214 @@
+package engine
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	minemath "github.com/wmattei/minceraft/math"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+const (
+	SHADOW_CASCADES = 3
+	SHADOW_MAP_SIZE = 2048
+
+	// SHADOW_DISTANCE is how far from the camera shadows are drawn.
+	SHADOW_DISTANCE = 192
+	// SHADOW_CASTER_MARGIN extends the depth range of every cascade towards
+	// the light so casters outside the view still throw their shadows in.
+	SHADOW_CASTER_MARGIN = 192
+	// SHADOW_SPLIT_LAMBDA blends logarithmic (1) and uniform (0) splits.
+	SHADOW_SPLIT_LAMBDA = 0.75
+
+	// SHADOW_TEXTURE_UNIT is the texture unit the chunk program samples the
+	// shadow map from.
+	SHADOW_TEXTURE_UNIT = 15
+)
+
+// ShadowCascade covers the slice of the view frustum up to Far with its own
+// layer of the shadow map.
+type ShadowCascade struct {
+	ViewProjection minemath.Mat4
+	Far            float32
+	// TexelSize is the size of a shadow map texel in world units.
+	TexelSize float32
+}
+
+// ShadowMap renders the depth of the world from the directional light into a
+// cascade of orthographic shadow maps fitted to the view frustum.
+type ShadowMap struct {
+	Cascades [SHADOW_CASCADES]ShadowCascade
+
+	Program *ShaderProgram
+
+	fbo      uint32
+	texture  uint32
+	viewport [4]int32
+}
+
+func NewShadowMap(shaders fs.FS) *ShadowMap {
+	s := &ShadowMap{Program: MustLoadShaderProgram(shaders, "shadow.vert", "shadow.frag")}
+
+	gl.GenTextures(1, &s.texture)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, s.texture)
+	gl.TexImage3D(gl.TEXTURE_2D_ARRAY, 0, gl.DEPTH_COMPONENT24, SHADOW_MAP_SIZE, SHADOW_MAP_SIZE, SHADOW_CASCADES, 0, gl.DEPTH_COMPONENT, gl.FLOAT, nil)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
+	border := [4]float32{1, 1, 1, 1}
+	gl.TexParameterfv(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_BORDER_COLOR, &border[0])
+	// Hardware depth comparison gives bilinear filtered lookups for free.
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_COMPARE_FUNC, gl.LEQUAL)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, 0)
+
+	gl.GenFramebuffers(1, &s.fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, s.fbo)
+	gl.FramebufferTextureLayer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, s.texture, 0, 0)
+	gl.DrawBuffer(gl.NONE)
+	gl.ReadBuffer(gl.NONE)
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		panic(fmt.Sprintf("shadow map framebuffer is incomplete: 0x%x", status))
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	return s
+}
+
+// cascadeSplits returns the far distance of every cascade, blending uniform
+// and logarithmic splits of the shadowed part of the view.
+func cascadeSplits(near float32) [SHADOW_CASCADES]float32 {
+	near = max(near, 1)
+	var splits [SHADOW_CASCADES]float32
+	for i := range splits {
+		p := float64(i+1) / SHADOW_CASCADES
+		logarithmic := float64(near) * math.Pow(SHADOW_DISTANCE/float64(near), p)
+		uniform := float64(near) + (SHADOW_DISTANCE-float64(near))*p
+		splits[i] = float32(SHADOW_SPLIT_LAMBDA*logarithmic + (1-SHADOW_SPLIT_LAMBDA)*uniform)
+	}
+	return splits
+}
+
+// Fit fits every cascade around its slice of the camera frustum, as seen
+// looking along the light direction, which points towards the light.
+func (s *ShadowMap) Fit(camera *render.PerspectiveCamera, lightDirection minemath.Vec3) {
+	up := minemath.Vec3{0, 1, 0}
+	if math.Abs(float64(lightDirection[1])) > 0.99 {
+		up = minemath.Vec3{0, 0, 1}
+	}
+	lightView := minemath.LookAt(minemath.Vec3{}, lightDirection.Negate(), up)
+
+	front, right, cameraUp := camera.Basis()
+	tanHalfFov := float32(math.Tan(float64(camera.FOV() / 2)))
+	near := camera.Near()
+
+	for i, far := range cascadeSplits(camera.Near()) {
+		// A bounding sphere keeps the size of the cascade stable while the
+		// camera turns, so shadow edges don't shimmer.
+		var corners [8]minemath.Vec3
+		n := 0
+		for _, distance := range [2]float32{near, far} {
+			halfHeight := distance * tanHalfFov
+			halfWidth := halfHeight * camera.Aspect()
+			center := minemath.Add(*camera.Position, front.Mul(distance))
+			for _, sx := range [2]float32{-1, 1} {
+				for _, sy := range [2]float32{-1, 1} {
+					corner := minemath.Add(center, right.Mul(sx*halfWidth))
+					corners[n] = minemath.Add(corner, cameraUp.Mul(sy*halfHeight))
+					n++
+				}
+			}
+		}
+		var center minemath.Vec3
+		for _, corner := range corners {
+			center = minemath.Add(center, corner.Mul(1.0/8))
+		}
+		var radius float32
+		for _, corner := range corners {
+			radius = max(radius, minemath.Subtract(corner, center).Len())
+		}
+		radius = float32(math.Ceil(float64(radius)))
+
+		// Snapping the center to whole texels stops the shadows from
+		// crawling as the camera moves.
+		texelSize := 2 * radius / SHADOW_MAP_SIZE
+		lightCenter := minemath.TransformVec3(lightView, center)
+		lightCenter[0] = float32(math.Floor(float64(lightCenter[0]/texelSize))) * texelSize
+		lightCenter[1] = float32(math.Floor(float64(lightCenter[1]/texelSize))) * texelSize
+
+		projection := minemath.GetOrthographicProjectionMatrix(
+			lightCenter[0]-radius, lightCenter[0]+radius,
+			lightCenter[1]-radius, lightCenter[1]+radius,
+			-lightCenter[2]-radius-SHADOW_CASTER_MARGIN, -lightCenter[2]+radius,
+		)
+
+		s.Cascades[i] = ShadowCascade{
+			ViewProjection: minemath.MultiplyMatrices(projection, lightView),
+			Far:            far,
+			TexelSize:      texelSize,
+		}
+		near = far
+	}
+}
+
+// BeginCascade binds the layer of the cascade for rendering and returns the
+// depth program, with the view and projection of the cascade set.
+func (s *ShadowMap) BeginCascade(cascade int) *ShaderProgram {
+	if cascade == 0 {
+		gl.GetIntegerv(gl.VIEWPORT, &s.viewport[0])
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, s.fbo)
+	gl.FramebufferTextureLayer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, s.texture, 0, int32(cascade))
+	gl.Viewport(0, 0, SHADOW_MAP_SIZE, SHADOW_MAP_SIZE)
+	gl.Clear(gl.DEPTH_BUFFER_BIT)
+
+	// Slope scaled bias keeps surfaces from shadowing themselves.
+	gl.Enable(gl.POLYGON_OFFSET_FILL)
+	gl.PolygonOffset(2, 4)
+
+	s.Program.Use()
+	viewProjection := s.Cascades[cascade].ViewProjection.Flatten()
+	gl.UniformMatrix4fv(s.Program.Uniform("lightViewProjection"), 1, false, &viewProjection[0])
+
+	return s.Program
+}
+
+// End restores the framebuffer and viewport used before the first cascade.
+func (s *ShadowMap) End() {
+	gl.Disable(gl.POLYGON_OFFSET_FILL)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(s.viewport[0], s.viewport[1], s.viewport[2], s.viewport[3])
+}
+
+// Binding is the depth texture, for the material of the programs sampling it.
+func (s *ShadowMap) Binding() render.TextureBinding {
+	return render.TextureBinding{Unit: SHADOW_TEXTURE_UNIT, Target: gl.TEXTURE_2D_ARRAY, Texture: s.texture, Sampler: "shadowMap"}
+}
+
+// Bind makes the cascades available to the chunk program, which must be in
+// use. The depth texture is bound through Binding.
+func (s *ShadowMap) Bind(program render.Program) {
+	var matrices [SHADOW_CASCADES * 16]float32
+	var splits, offsets [SHADOW_CASCADES]float32
+	for i, cascade := range s.Cascades {
+		flat := cascade.ViewProjection.Flatten()
+		copy(matrices[i*16:], flat[:])
+		splits[i] = cascade.Far
+		// Pushing the lookup along the normal by about a texel hides the
+		// acne polygon offset alone leaves on grazing faces.
+		offsets[i] = cascade.TexelSize * 1.5
+	}
+	gl.UniformMatrix4fv(program.Uniform("lightViewProjections"), SHADOW_CASCADES, false, &matrices[0])
+	gl.Uniform1fv(program.Uniform("cascadeSplits"), SHADOW_CASCADES, &splits[0])
+	gl.Uniform1fv(program.Uniform("shadowNormalOffsets"), SHADOW_CASCADES, &offsets[0])
+}
+
+func (s *ShadowMap) Delete() {
+	s.Program.Delete()
+	gl.DeleteFramebuffers(1, &s.fbo)
+	gl.DeleteTextures(1, &s.texture)
+}
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"io/fs"
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	minemath "github.com/wmattei/minceraft/math"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+// Sky draws the sky gradient, the sun and the moon behind everything else.
+type Sky struct {
+	Program *ShaderProgram
+	vao     uint32
+}
+
+func NewSky(shaders fs.FS) *Sky {
+	sky := &Sky{Program: MustLoadShaderProgram(shaders, "sky.vert", "sky.frag")}
+
+	// The core profile won't draw without a vertex array bound, even an empty one.
+	gl.GenVertexArrays(1, &sky.vao)
+
+	return sky
+}
+
+// Render clears the screen and draws the sky as seen from the camera. Colours
+// are RGB in the [0, 1] range.
+func (s *Sky) Render(camera *render.PerspectiveCamera, zenith, horizon, sunDirection minemath.Vec3) {
+	gl.ClearColor(horizon[0], horizon[1], horizon[2], 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	s.Program.Use()
+
+	front, right, up := camera.Basis()
+	tanHalfFov := float32(math.Tan(float64(camera.FOV() / 2)))
+	gl.Uniform3f(s.Program.Uniform("cameraFront"), front[0], front[1], front[2])
+	gl.Uniform3f(s.Program.Uniform("cameraRight"), right[0], right[1], right[2])
+	gl.Uniform3f(s.Program.Uniform("cameraUp"), up[0], up[1], up[2])
+	gl.Uniform2f(s.Program.Uniform("viewScale"), tanHalfFov*camera.Aspect(), tanHalfFov)
+	gl.Uniform3f(s.Program.Uniform("zenithColor"), zenith[0], zenith[1], zenith[2])
+	gl.Uniform3f(s.Program.Uniform("horizonColor"), horizon[0], horizon[1], horizon[2])
+	gl.Uniform3f(s.Program.Uniform("sunDirection"), sunDirection[0], sunDirection[1], sunDirection[2])
+
+	gl.Disable(gl.DEPTH_TEST)
+	gl.DepthMask(false)
+	gl.BindVertexArray(s.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	gl.BindVertexArray(0)
+	gl.DepthMask(true)
+	gl.Enable(gl.DEPTH_TEST)
+}
+
+func (s *Sky) Delete() {
+	s.Program.Delete()
+	gl.DeleteVertexArrays(1, &s.vao)
+}
@@ -3,46 +3,124 @@ package engine
 import (
 	"fmt"
 	"image"
-	"image/png"
-	"os"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/wmattei/minceraft/pkg/render"
 )
 
-func LoadTexture(path string) (uint32, error) {
-	imgFile, err := os.Open(path)
-	if err != nil {
-		return 0, err
+const (
+	// SPRITE_TEXELS is the height of the sprite table.
+	SPRITE_TEXELS = 3
+
+	TEXTURE_ARRAY_UNIT = 0
+	SPRITES_UNIT       = 1
+)
+
+// TextureArray holds equally sized atlas pages as the layers of a single
+// GL_TEXTURE_2D_ARRAY. Vertices pick a sprite from the table stored next to
+// it, one column of texels per sprite: its rectangle, its tint and page, then
+// the corner of its next frame and how far it is blended in.
+type TextureArray struct {
+	ID      uint32
+	Sprites uint32
+	Size    int
+	Layers  int
+
+	spriteCount int
+}
+
+// NewTextureArray uploads the pages with mipLevels mipmap levels, the full
+// size one included.
+func NewTextureArray(pages []*image.RGBA, mipLevels int, sprites []render.Sprite) (*TextureArray, error) {
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("a texture array needs at least one page")
+	}
+	if len(sprites) == 0 || len(sprites) > render.MAX_SPRITES {
+		return nil, fmt.Errorf("a texture array needs between 1 and %d sprites, got %d", render.MAX_SPRITES, len(sprites))
 	}
-	defer imgFile.Close()
 
-	img, err := png.Decode(imgFile)
-	if err != nil {
-		return 0, err
+	t := &TextureArray{Size: pages[0].Rect.Dx(), Layers: len(pages), spriteCount: len(sprites)}
+	for _, page := range pages {
+		if page.Rect.Dx() != t.Size || page.Rect.Dy() != t.Size {
+			return nil, fmt.Errorf("page of %v in a %d pixel texture array", page.Rect.Size(), t.Size)
+		}
 	}
 
-	rgba := image.NewRGBA(img.Bounds())
-	if rgba.Stride != rgba.Rect.Size().X*4 {
-		return 0, fmt.Errorf("unsupported stride")
+	gl.GenTextures(1, &t.ID)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, t.ID)
+	gl.TexImage3D(gl.TEXTURE_2D_ARRAY, 0, gl.RGBA8, int32(t.Size), int32(t.Size), int32(t.Layers), 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	for i, page := range pages {
+		gl.TexSubImage3D(gl.TEXTURE_2D_ARRAY, 0, 0, 0, int32(i), int32(t.Size), int32(t.Size), 1, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(page.Pix))
 	}
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MAX_LEVEL, int32(mipLevels-1))
+	gl.GenerateMipmap(gl.TEXTURE_2D_ARRAY)
 
-	for y := 0; y < img.Bounds().Dy(); y++ {
-		for x := 0; x < img.Bounds().Dx(); x++ {
-			rgba.Set(x, y, img.At(x, y))
-		}
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, 0)
+
+	gl.GenTextures(1, &t.Sprites)
+	gl.BindTexture(gl.TEXTURE_2D, t.Sprites)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA32F, int32(len(sprites)), SPRITE_TEXELS, 0, gl.RGBA, gl.FLOAT, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	for i, sprite := range sprites {
+		t.uploadSprite(i, sprite)
 	}
+	gl.BindTexture(gl.TEXTURE_2D, 0)
 
-	var texture uint32
-	gl.GenTextures(1, &texture)
-	gl.BindTexture(gl.TEXTURE_2D, texture)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(rgba.Rect.Size().X), int32(rgba.Rect.Size().Y), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
-	gl.GenerateMipmap(gl.TEXTURE_2D)
+	return t, nil
+}
 
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+// SetSprite replaces a sprite, every face drawn with it follows without being
+// remeshed.
+func (t *TextureArray) SetSprite(index int, sprite render.Sprite) {
+	if index < 0 || index >= t.spriteCount {
+		return
+	}
+	gl.BindTexture(gl.TEXTURE_2D, t.Sprites)
+	t.uploadSprite(index, sprite)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
 
-	return texture, nil
+func (t *TextureArray) uploadSprite(index int, sprite render.Sprite) {
+	texels := spriteTexels(sprite)
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, int32(index), 0, 1, SPRITE_TEXELS, gl.RGBA, gl.FLOAT, gl.Ptr(&texels[0]))
+}
 
+// spriteTexels lays a sprite out as its column of the sprite table.
+func spriteTexels(sprite render.Sprite) [SPRITE_TEXELS * 4]float32 {
+	uv := sprite.UV
+	return [SPRITE_TEXELS * 4]float32{
+		uv[0], uv[1], uv[2] - uv[0], uv[3] - uv[1],
+		sprite.Tint[0], sprite.Tint[1], sprite.Tint[2], float32(sprite.Page),
+		sprite.NextUV[0], sprite.NextUV[1], sprite.Blend, 0,
+	}
+}
+
+// Bindings are the pages and the sprite table, for the material of the
+// programs drawing with them.
+func (t *TextureArray) Bindings() []render.TextureBinding {
+	return []render.TextureBinding{
+		{Unit: TEXTURE_ARRAY_UNIT, Target: gl.TEXTURE_2D_ARRAY, Texture: t.ID, Sampler: "blockTextures"},
+		{Unit: SPRITES_UNIT, Target: gl.TEXTURE_2D, Texture: t.Sprites, Sampler: "sprites"},
+	}
+}
+
+func (t *TextureArray) Delete() {
+	gl.DeleteTextures(1, &t.ID)
+	gl.DeleteTextures(1, &t.Sprites)
+}
+
+// GLTextureUploader stores atlases in texture arrays.
+type GLTextureUploader struct{}
+
+func (GLTextureUploader) UploadTextures(pages []*image.RGBA, mipLevels int, sprites []render.Sprite) (render.SpriteTable, error) {
+	array, err := NewTextureArray(pages, mipLevels, sprites)
+	if err != nil {
+		return nil, err
+	}
+	return array, nil
 }
@@ -0,0 +1,38 @@
+package render
+
+const (
+	// VERTEX_BLOCK is the number of vertices of a block of a mesh pool. Meshes
+	// get whole blocks, so shaders find the origin of the mesh a vertex
+	// belongs to at gl_VertexID / VERTEX_BLOCK. It must match
+	// packed_vertex.glsl.
+	VERTEX_BLOCK = 256
+
+	INITIAL_VERTEX_BLOCKS = 4096
+	INITIAL_POOL_INDICES  = INITIAL_VERTEX_BLOCKS * VERTEX_BLOCK * 3 / 2
+)
+
+// Allocation is a mesh stored by a MeshUploader. Its indices start at 0, they
+// are offset by BaseVertex when drawn.
+type Allocation struct {
+	VAO        uint32
+	FirstBlock int
+	Blocks     int
+	FirstIndex int
+	IndexCount int
+}
+
+// BaseVertex is the first vertex of the mesh in the pool.
+func (a *Allocation) BaseVertex() int {
+	return a.FirstBlock * VERTEX_BLOCK
+}
+
+// DrawCall draws the mesh with a material.
+func (a *Allocation) DrawCall(material *Material) DrawCall {
+	return DrawCall{
+		Material:   material,
+		VAO:        a.VAO,
+		FirstIndex: int32(a.FirstIndex),
+		IndexCount: int32(a.IndexCount),
+		BaseVertex: int32(a.BaseVertex()),
+	}
+}
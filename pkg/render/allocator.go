@@ -0,0 +1,80 @@
+package render
+
+import "sort"
+
+// Span is a range of units of a buffer.
+type Span struct {
+	Offset int
+	Length int
+}
+
+// Allocator hands out ranges of a buffer of Size units, first fit. Freed
+// ranges are merged with the free ranges around them.
+type Allocator struct {
+	Size int
+
+	// free is sorted by offset and never holds two touching spans.
+	free []Span
+	used int
+}
+
+func NewAllocator(size int) *Allocator {
+	return &Allocator{Size: size, free: []Span{{0, size}}}
+}
+
+// Alloc returns the offset of length free units, false when no free range is
+// long enough.
+func (a *Allocator) Alloc(length int) (int, bool) {
+	for i, span := range a.free {
+		if span.Length < length {
+			continue
+		}
+
+		if span.Length == length {
+			a.free = append(a.free[:i], a.free[i+1:]...)
+		} else {
+			a.free[i] = Span{span.Offset + length, span.Length - length}
+		}
+		a.used += length
+		return span.Offset, true
+	}
+	return 0, false
+}
+
+// Free gives back a range returned by Alloc.
+func (a *Allocator) Free(offset, length int) {
+	a.used -= length
+	i := sort.Search(len(a.free), func(i int) bool { return a.free[i].Offset > offset })
+
+	mergesBefore := i > 0 && a.free[i-1].Offset+a.free[i-1].Length == offset
+	mergesAfter := i < len(a.free) && offset+length == a.free[i].Offset
+	switch {
+	case mergesBefore && mergesAfter:
+		a.free[i-1].Length += length + a.free[i].Length
+		a.free = append(a.free[:i], a.free[i+1:]...)
+	case mergesBefore:
+		a.free[i-1].Length += length
+	case mergesAfter:
+		a.free[i] = Span{offset, length + a.free[i].Length}
+	default:
+		a.free = append(a.free, Span{})
+		copy(a.free[i+1:], a.free[i:])
+		a.free[i] = Span{offset, length}
+	}
+}
+
+// Grow makes the buffer size units long, the new units being free.
+func (a *Allocator) Grow(size int) {
+	if size <= a.Size {
+		return
+	}
+	added := size - a.Size
+	a.used += added
+	a.Free(a.Size, added)
+	a.Size = size
+}
+
+// Used is the number of allocated units.
+func (a *Allocator) Used() int {
+	return a.used
+}
@@ -0,0 +1,35 @@
+package render
+
+import "testing"
+
+func TestAllocatorReusesAndMergesRanges(t *testing.T) {
+	a := NewAllocator(100)
+
+	first, _ := a.Alloc(30)
+	second, _ := a.Alloc(30)
+	third, _ := a.Alloc(30)
+	if first != 0 || second != 30 || third != 60 {
+		t.Fatalf("allocated at %d, %d and %d", first, second, third)
+	}
+	if _, ok := a.Alloc(20); ok {
+		t.Error("allocated past the end of the buffer")
+	}
+
+	a.Free(first, 30)
+	a.Free(third, 30)
+	if offset, ok := a.Alloc(20); !ok || offset != 0 {
+		t.Errorf("a freed range wasn't reused, got %d", offset)
+	}
+	a.Free(0, 20)
+
+	a.Free(second, 30)
+	if len(a.free) != 1 || a.free[0] != (Span{0, 100}) || a.Used() != 0 {
+		t.Errorf("freeing everything left %v with %d used", a.free, a.Used())
+	}
+
+	a.Alloc(100)
+	a.Grow(150)
+	if offset, ok := a.Alloc(50); !ok || offset != 100 || a.Used() != 150 {
+		t.Errorf("after growing allocated at %d (%v), %d used", offset, ok, a.Used())
+	}
+}
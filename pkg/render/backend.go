@@ -0,0 +1,123 @@
+package render
+
+import minemath "github.com/wmattei/minceraft/math"
+
+// Renderer draws the calls submitted to it, in batches, on Flush.
+// engine.GLRenderer draws with OpenGL and RecordingRenderer only records the
+// calls, for tests and servers without a GPU.
+type Renderer interface {
+	Submit(call DrawCall)
+	Flush()
+	// Stats counts what the last flush did.
+	Stats() RenderStats
+}
+
+// MeshUploader stores meshes where a Renderer can draw them from.
+// engine.GeometryPool stores them on the GPU and RecordingMeshUploader only
+// keeps track of them.
+type MeshUploader interface {
+	// Upload stores a mesh whose vertices are placed relative to origin.
+	Upload(vertices []uint32, indices []uint32, origin minemath.Vec3) *Allocation
+	Free(a *Allocation)
+	// Bindings are the textures the materials drawing the meshes need.
+	Bindings() []TextureBinding
+	Delete()
+}
+
+// RecordingRenderer is a Renderer that keeps the calls of the last flush,
+// in the order engine.GLRenderer would draw them, instead of drawing them.
+type RecordingRenderer struct {
+	Flushed []DrawCall
+	Flushes int
+
+	calls []DrawCall
+	stats RenderStats
+}
+
+func NewRecordingRenderer() *RecordingRenderer {
+	return &RecordingRenderer{}
+}
+
+func (r *RecordingRenderer) Submit(call DrawCall) {
+	r.calls = append(r.calls, call)
+}
+
+func (r *RecordingRenderer) Flush() {
+	SortDrawCalls(r.calls)
+	r.stats = RenderStats{Meshes: len(r.calls)}
+	var material *Material
+	Batches(r.calls, func(batch []DrawCall) {
+		if batch[0].Material != material {
+			material = batch[0].Material
+			r.stats.Materials++
+		}
+		r.stats.DrawCalls++
+	})
+
+	r.Flushed = append(r.Flushed[:0], r.calls...)
+	r.calls = r.calls[:0]
+	r.Flushes++
+}
+
+func (r *RecordingRenderer) Stats() RenderStats {
+	return r.stats
+}
+
+// RecordingMeshUploader is a MeshUploader that hands out allocations as
+// engine.GeometryPool would, without storing the meshes anywhere.
+type RecordingMeshUploader struct {
+	Uploads int
+	Frees   int
+
+	vertexSize   int
+	vertexBlocks *Allocator
+	indices      *Allocator
+}
+
+func NewRecordingMeshUploader(vertexSize int) *RecordingMeshUploader {
+	return &RecordingMeshUploader{
+		vertexSize:   vertexSize,
+		vertexBlocks: NewAllocator(INITIAL_VERTEX_BLOCKS),
+		indices:      NewAllocator(INITIAL_POOL_INDICES),
+	}
+}
+
+func (u *RecordingMeshUploader) Upload(vertices []uint32, indices []uint32, origin minemath.Vec3) *Allocation {
+	blocks := (len(vertices)*4/u.vertexSize + VERTEX_BLOCK - 1) / VERTEX_BLOCK
+	firstBlock, ok := u.vertexBlocks.Alloc(blocks)
+	for !ok {
+		u.vertexBlocks.Grow(u.vertexBlocks.Size * 2)
+		firstBlock, ok = u.vertexBlocks.Alloc(blocks)
+	}
+	firstIndex, ok := u.indices.Alloc(len(indices))
+	for !ok {
+		u.indices.Grow(u.indices.Size * 2)
+		firstIndex, ok = u.indices.Alloc(len(indices))
+	}
+
+	u.Uploads++
+	return &Allocation{
+		VAO:        1,
+		FirstBlock: firstBlock,
+		Blocks:     blocks,
+		FirstIndex: firstIndex,
+		IndexCount: len(indices),
+	}
+}
+
+func (u *RecordingMeshUploader) Free(a *Allocation) {
+	u.vertexBlocks.Free(a.FirstBlock, a.Blocks)
+	u.indices.Free(a.FirstIndex, a.IndexCount)
+	u.Frees++
+}
+
+// Live is the number of meshes uploaded and not freed.
+func (u *RecordingMeshUploader) Live() int {
+	return u.Uploads - u.Frees
+}
+
+func (u *RecordingMeshUploader) Bindings() []TextureBinding {
+	return nil
+}
+
+func (u *RecordingMeshUploader) Delete() {}
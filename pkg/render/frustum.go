@@ -1,4 +1,4 @@
-package engine
+package render
 
 import (
 	"math"
@@ -25,19 +25,79 @@ func (p *Plane) Normalize() {
 	p.Distance /= length
 }
 
+// Frustum is the volume seen by a camera, as six planes whose normals point
+// inside.
 type Frustum struct {
-	topPlane    *Plane
-	bottomPlane *Plane
-	leftPlane   *Plane
-	rightPlane  *Plane
-	nearPlane   *Plane
-	farPlane    *Plane
+	planes [6]Plane
 
 	camera *PerspectiveCamera
 }
 
+// Containment is how a volume lies relative to a frustum.
+type Containment int
+
+const (
+	Outside Containment = iota
+	Intersecting
+	Inside
+)
+
+func (c Containment) String() string {
+	switch c {
+	case Outside:
+		return "outside"
+	case Intersecting:
+		return "intersecting"
+	case Inside:
+		return "inside"
+	}
+	return "unknown"
+}
+
+// GetPlanes returns the left, right, bottom, top, near and far planes.
 func (f *Frustum) GetPlanes() []Plane {
-	return []Plane{*f.topPlane, *f.bottomPlane, *f.leftPlane, *f.rightPlane, *f.nearPlane, *f.farPlane}
+	return f.planes[:]
+}
+
+// TestAABB tells where the world-space box between min and max lies. Only
+// the corner furthest along the normal of each plane, and the one opposite to
+// it, are checked.
+func (f *Frustum) TestAABB(min, max minemath.Vec3) Containment {
+	result := Inside
+	for i := range f.planes {
+		plane := &f.planes[i]
+		var positive, negative minemath.Vec3
+		for axis := 0; axis < 3; axis++ {
+			if plane.Normal[axis] >= 0 {
+				positive[axis], negative[axis] = max[axis], min[axis]
+			} else {
+				positive[axis], negative[axis] = min[axis], max[axis]
+			}
+		}
+
+		if plane.DistanceToPoint(positive) < 0 {
+			return Outside
+		}
+		if plane.DistanceToPoint(negative) < 0 {
+			result = Intersecting
+		}
+	}
+	return result
+}
+
+// TestSphere tells where the world-space sphere lies.
+func (f *Frustum) TestSphere(center minemath.Vec3, radius float32) Containment {
+	result := Inside
+	for i := range f.planes {
+		distance := f.planes[i].DistanceToPoint(center)
+		if distance < -radius {
+			return Outside
+		}
+		if distance < radius {
+			result = Intersecting
+		}
+	}
+	return result
 }
 
 func NewFrustum(camera *PerspectiveCamera) *Frustum {
@@ -47,8 +107,9 @@ func NewFrustum(camera *PerspectiveCamera) *Frustum {
 	return frustum
 }
 
+// UpdateFrustum extracts the planes from the projection times view matrix.
 func (f *Frustum) UpdateFrustum(viewProjMatrix minemath.Mat4) {
-	var planes [6]Plane
+	planes := &f.planes
 
 	// Left plane
 	planes[0] = Plane{
@@ -109,18 +170,8 @@ func (f *Frustum) UpdateFrustum(viewProjMatrix minemath.Mat4) {
 		},
 		Distance: viewProjMatrix[3][3] - viewProjMatrix[2][3],
 	}
-	// // Normalize the planes
+	// Normalize the planes
 	for i := 0; i < 6; i++ {
 		planes[i].Normalize()
 	}
-
-	f.leftPlane = &planes[0]
-	f.rightPlane = &planes[1]
-	f.bottomPlane = &planes[2]
-	f.topPlane = &planes[3]
-	f.nearPlane = &planes[4]
-	f.farPlane = &planes[5]
-
-	// fmt.Println(f.leftPlane)
-
 }
@@ -0,0 +1,57 @@
+package render
+
+import (
+	"math"
+	"testing"
+
+	minemath "github.com/wmattei/minceraft/math"
+)
+
+// lookingDownZ sees from the origin towards -z, 90 degrees wide, between 1
+// and 100 units away.
+func lookingDownZ() *Frustum {
+	f := &Frustum{}
+	f.UpdateFrustum(minemath.GetPerspectiveProjectionMatrix(math.Pi/2, 1, 1, 100))
+	return f
+}
+
+func TestFrustumAABB(t *testing.T) {
+	f := lookingDownZ()
+
+	for _, box := range []struct {
+		name     string
+		min, max minemath.Vec3
+		want     Containment
+	}{
+		{"ahead", minemath.Vec3{-1, -1, -11}, minemath.Vec3{1, 1, -9}, Inside},
+		{"across the right plane", minemath.Vec3{8, -1, -11}, minemath.Vec3{12, 1, -9}, Intersecting},
+		{"around the camera", minemath.Vec3{-16, -16, -16}, minemath.Vec3{16, 16, 16}, Intersecting},
+		{"behind", minemath.Vec3{-1, -1, 5}, minemath.Vec3{1, 1, 10}, Outside},
+		{"right of the frustum", minemath.Vec3{20, -1, -11}, minemath.Vec3{22, 1, -9}, Outside},
+		{"past the far plane", minemath.Vec3{-1, -1, -120}, minemath.Vec3{1, 1, -110}, Outside},
+	} {
+		if got := f.TestAABB(box.min, box.max); got != box.want {
+			t.Errorf("the box %s is %v, want %v", box.name, got, box.want)
+		}
+	}
+}
+
+func TestFrustumSphere(t *testing.T) {
+	f := lookingDownZ()
+
+	for _, sphere := range []struct {
+		name   string
+		center minemath.Vec3
+		radius float32
+		want   Containment
+	}{
+		{"ahead", minemath.Vec3{0, 0, -10}, 1, Inside},
+		{"across the top plane", minemath.Vec3{0, 10, -10}, 1, Intersecting},
+		{"behind", minemath.Vec3{0, 0, 10}, 2, Outside},
+		{"below the frustum", minemath.Vec3{0, -15, -10}, 2, Outside},
+	} {
+		if got := f.TestSphere(sphere.center, sphere.radius); got != sphere.want {
+			t.Errorf("the sphere %s is %v, want %v", sphere.name, got, sphere.want)
+		}
+	}
+}
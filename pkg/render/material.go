@@ -0,0 +1,87 @@
+package render
+
+import "sort"
+
+// Program is a shader program of the backend drawing the calls. Its ID
+// changes when it is rebuilt.
+type Program interface {
+	ProgramID() uint32
+	// Uniform returns the location of a uniform, -1 when the program has none
+	// by that name.
+	Uniform(name string) int32
+}
+
+// TextureBinding is a texture bound to a unit, with the sampler uniform of a
+// material pointing at it.
+type TextureBinding struct {
+	Unit    uint32
+	Target  uint32
+	Texture uint32
+	Sampler string
+}
+
+// Material is a program with the textures and uniforms shared by everything
+// drawn with it.
+type Material struct {
+	Name     string
+	Program  Program
+	Textures []TextureBinding
+	// Bind, when set, uploads the uniforms of the material. It runs once per
+	// flush, before the first draw using the material.
+	Bind func(program Program)
+
+	id uint32
+}
+
+var nextMaterialID uint32
+
+func NewMaterial(name string, program Program, textures ...TextureBinding) *Material {
+	nextMaterialID++
+	return &Material{Name: name, Program: program, Textures: textures, id: nextMaterialID}
+}
+
+// DrawCall draws IndexCount indexed triangles of a vertex array, starting at
+// FirstIndex, with a material. Indices are offset by BaseVertex.
+type DrawCall struct {
+	Material   *Material
+	VAO        uint32
+	FirstIndex int32
+	IndexCount int32
+	BaseVertex int32
+}
+
+// RenderStats counts what the last flush of a renderer did.
+type RenderStats struct {
+	// Meshes counts the submitted draw calls and DrawCalls the GL calls they
+	// were batched into.
+	Meshes    int
+	DrawCalls int
+	Materials int
+	// StateChanges and SkippedChanges count the state changes that reached
+	// the backend and those that were redundant.
+	StateChanges   int
+	SkippedChanges int
+}
+
+// SortDrawCalls groups the calls by material, then by vertex array, keeping
+// the submission order otherwise.
+func SortDrawCalls(calls []DrawCall) {
+	sort.SliceStable(calls, func(i, j int) bool {
+		if calls[i].Material.id != calls[j].Material.id {
+			return calls[i].Material.id < calls[j].Material.id
+		}
+		return calls[i].VAO < calls[j].VAO
+	})
+}
+
+// Batches calls fn with every run of calls sharing a material and a vertex
+// array, in order.
+func Batches(calls []DrawCall, fn func(batch []DrawCall)) {
+	start := 0
+	for i := 1; i <= len(calls); i++ {
+		if i == len(calls) || calls[i].Material != calls[start].Material || calls[i].VAO != calls[start].VAO {
+			fn(calls[start:i])
+			start = i
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package render
+
+import "testing"
+
+func TestDrawCallsAreGroupedByMaterial(t *testing.T) {
+	terrain := NewMaterial("terrain", nil)
+	water := NewMaterial("water", nil)
+
+	calls := []DrawCall{
+		{Material: water, VAO: 4},
+		{Material: terrain, VAO: 3},
+		{Material: water, VAO: 1},
+		{Material: terrain, VAO: 2},
+		{Material: terrain, VAO: 3, IndexCount: 6},
+	}
+	SortDrawCalls(calls)
+
+	want := []struct {
+		material   *Material
+		vao        uint32
+		indexCount int32
+	}{{terrain, 2, 0}, {terrain, 3, 0}, {terrain, 3, 6}, {water, 1, 0}, {water, 4, 0}}
+	for i, call := range calls {
+		if call.Material != want[i].material || call.VAO != want[i].vao || call.IndexCount != want[i].indexCount {
+			t.Errorf("call %d draws vertex array %d (%d indices) with %s, want %d (%d) with %s", i, call.VAO, call.IndexCount, call.Material.Name, want[i].vao, want[i].indexCount, want[i].material.Name)
+		}
+	}
+}
+
+func TestCallsSharingAVertexArrayAreBatched(t *testing.T) {
+	terrain := NewMaterial("terrain", nil)
+	water := NewMaterial("water", nil)
+
+	calls := []DrawCall{
+		{Material: terrain, VAO: 1, FirstIndex: 0},
+		{Material: water, VAO: 1, FirstIndex: 6},
+		{Material: terrain, VAO: 1, FirstIndex: 12},
+		{Material: terrain, VAO: 2, FirstIndex: 0},
+	}
+	SortDrawCalls(calls)
+
+	var sizes []int
+	Batches(calls, func(batch []DrawCall) { sizes = append(sizes, len(batch)) })
+	if len(sizes) != 3 || sizes[0] != 2 || sizes[1] != 1 || sizes[2] != 1 {
+		t.Errorf("batched into %v, want [2 1 1]", sizes)
+	}
+}
@@ -1,4 +1,4 @@
-package engine
+package render
 
 import (
 	"math"
@@ -45,6 +45,29 @@ func (cam *PerspectiveCamera) GetProjectionMatrix() minemath.Mat4 {
 	return minemath.GetPerspectiveProjectionMatrix(cam.fov, cam.aspect, cam.near, cam.far)
 }
 
+// FOV is the vertical field of view, in radians.
+func (cam *PerspectiveCamera) FOV() float32 {
+	return cam.fov
+}
+
+// Aspect is the width of the view divided by its height.
+func (cam *PerspectiveCamera) Aspect() float32 {
+	return cam.aspect
+}
+
+func (cam *PerspectiveCamera) Near() float32 {
+	return cam.near
+}
+
+// Basis returns the unit vectors pointing forward, right and up from the
+// camera.
+func (cam *PerspectiveCamera) Basis() (front, right, up minemath.Vec3) {
+	front = minemath.Normalize(cam.front)
+	right = minemath.Normalize(minemath.Cross(front, cam.worldUp))
+	up = minemath.Cross(right, front)
+	return front, right, up
+}
+
 func (cam *PerspectiveCamera) Move(x, y, z float32) {
 	cam.Position[0] += x
 	cam.Position[1] += y
@@ -0,0 +1,39 @@
+package render
+
+import (
+	"image"
+
+	minemath "github.com/wmattei/minceraft/math"
+)
+
+// MAX_SPRITES is the number of sprites the packed vertex format of the chunk
+// meshes can address.
+const MAX_SPRITES = 256
+
+// Sprite is a texture drawn from a rectangle, u0, v0, u1, v1, of a page of a
+// texture array and tinted by Tint. Animated sprites fade by Blend into the
+// frame at NextUV, a rectangle of the same size on the same page.
+type Sprite struct {
+	Page   int
+	UV     [4]float32
+	Tint   minemath.Vec3
+	NextUV [4]float32
+	Blend  float32
+}
+
+// TextureUploader stores the pages of an atlas, with mipLevels mipmap levels
+// the full size one included, and the sprites drawn from them.
+type TextureUploader interface {
+	UploadTextures(pages []*image.RGBA, mipLevels int, sprites []Sprite) (SpriteTable, error)
+}
+
+// SpriteTable is an atlas stored by a TextureUploader.
+type SpriteTable interface {
+	// SetSprite replaces a sprite, every face drawn with it follows without
+	// being remeshed.
+	SetSprite(index int, sprite Sprite)
+	// Bindings are the textures of the atlas, for the materials drawing with
+	// it.
+	Bindings() []TextureBinding
+	Delete()
+}
@@ -0,0 +1,207 @@
+// Package resources resolves assets through an ordered stack of resource
+// packs, directories or zip files, so a pack can override single files of the
+// packs below it.
+package resources
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Pack is a directory or a zip file of assets.
+type Pack struct {
+	fs.FS
+	Path string
+
+	closer io.Closer
+}
+
+func OpenPack(path string) (*Pack, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &Pack{FS: os.DirFS(path), Path: path}, nil
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		return nil, fmt.Errorf("%s is neither a directory nor a zip file", path)
+	}
+
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Pack{FS: reader, Path: path, closer: reader}, nil
+}
+
+func (p *Pack) Close() error {
+	if p.closer == nil {
+		return nil
+	}
+	return p.closer.Close()
+}
+
+// Layers is a file system stacked from others: a file is read from the first
+// layer that has it and directories list the files of every layer.
+type Layers []fs.FS
+
+func (l Layers) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, layer := range l {
+		file, err := layer.Open(name)
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (l Layers) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, layer := range l {
+		info, err := fs.Stat(layer, name)
+		if err == nil {
+			return info, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir merges the entries of the directory in every layer, sorted by name.
+func (l Layers) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	found := false
+	entries := make(map[string]fs.DirEntry)
+	for _, layer := range l {
+		layerEntries, err := fs.ReadDir(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		for _, entry := range layerEntries {
+			if _, ok := entries[entry.Name()]; !ok {
+				entries[entry.Name()] = entry
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	result := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+// Manager reads assets through a stack of packs, the first ones overriding
+// the following ones. Lookups are safe while it is reloaded, but a reload
+// closes the previous packs: files opened before it must not be read after
+// it.
+type Manager struct {
+	paths []string
+
+	mu     sync.RWMutex
+	packs  []*Pack
+	layers Layers
+}
+
+func NewManager(paths ...string) (*Manager, error) {
+	m := &Manager{paths: paths}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Packs returns the paths of the packs, from the one with the highest
+// priority.
+func (m *Manager) Packs() []string {
+	return m.paths
+}
+
+// Reload reopens every pack, picking up files added to or removed from them.
+// The previous packs are kept when one fails to open, and closed otherwise,
+// along with the files opened from them.
+func (m *Manager) Reload() error {
+	packs := make([]*Pack, 0, len(m.paths))
+	layers := make(Layers, 0, len(m.paths))
+	for _, path := range m.paths {
+		pack, err := OpenPack(path)
+		if err != nil {
+			for _, opened := range packs {
+				opened.Close()
+			}
+			return err
+		}
+		packs = append(packs, pack)
+		layers = append(layers, pack)
+	}
+
+	m.mu.Lock()
+	old := m.packs
+	m.packs, m.layers = packs, layers
+	m.mu.Unlock()
+
+	for _, pack := range old {
+		pack.Close()
+	}
+	return nil
+}
+
+func (m *Manager) current() Layers {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.layers
+}
+
+func (m *Manager) Open(name string) (fs.File, error) {
+	return m.current().Open(name)
+}
+
+func (m *Manager) Stat(name string) (fs.FileInfo, error) {
+	return m.current().Stat(name)
+}
+
+func (m *Manager) ReadDir(name string) ([]fs.DirEntry, error) {
+	return m.current().ReadDir(name)
+}
+
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var err error
+	for _, pack := range m.packs {
+		err = errors.Join(err, pack.Close())
+	}
+	m.packs, m.layers = nil, nil
+	return err
+}
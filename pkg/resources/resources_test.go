@@ -0,0 +1,93 @@
+package resources
+
+import (
+	"archive/zip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := zip.NewWriter(file)
+	for name, content := range files {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+}
+
+func TestManagerStacksPacks(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base")
+	os.MkdirAll(filepath.Join(base, "assets", "textures"), 0o755)
+	os.WriteFile(filepath.Join(base, "assets", "textures", "stone.png"), []byte("base stone"), 0o644)
+	os.WriteFile(filepath.Join(base, "assets", "textures", "dirt.png"), []byte("base dirt"), 0o644)
+
+	pack := filepath.Join(dir, "pack.zip")
+	writeZip(t, pack, map[string]string{
+		"assets/textures/stone.png": "pack stone",
+		"assets/textures/ore.png":   "pack ore",
+	})
+
+	m, err := NewManager(pack, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	for name, want := range map[string]string{
+		"assets/textures/stone.png": "pack stone",
+		"assets/textures/dirt.png":  "base dirt",
+		"assets/textures/ore.png":   "pack ore",
+	} {
+		if got, err := fs.ReadFile(m, name); err != nil || string(got) != want {
+			t.Errorf("%s is %q (%v), want %q", name, got, err, want)
+		}
+	}
+
+	entries, err := fs.ReadDir(m, "assets/textures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != 3 || names[0] != "dirt.png" || names[1] != "ore.png" || names[2] != "stone.png" {
+		t.Errorf("the merged directory lists %v", names)
+	}
+
+	if _, err := fs.ReadFile(m, "assets/missing.png"); err == nil {
+		t.Error("a missing file was found")
+	}
+
+	writeZip(t, pack, map[string]string{"assets/textures/stone.png": "new stone"})
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := fs.ReadFile(m, "assets/textures/stone.png"); string(got) != "new stone" {
+		t.Errorf("after a reload the stone is %q", got)
+	}
+	if _, err := fs.ReadFile(m, "assets/textures/ore.png"); err == nil {
+		t.Error("a file removed from the pack survived the reload")
+	}
+
+	os.Remove(pack)
+	if err := m.Reload(); err == nil {
+		t.Error("reloading a missing pack succeeded")
+	}
+	if got, _ := fs.ReadFile(m, "assets/textures/stone.png"); string(got) != "new stone" {
+		t.Errorf("a failed reload dropped the packs, the stone is %q", got)
+	}
+}
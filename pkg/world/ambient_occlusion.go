@@ -0,0 +1,95 @@
+package world
+
+var directionOffsets = [6][3]int{
+	Right:  {1, 0, 0},
+	Left:   {-1, 0, 0},
+	Top:    {0, 1, 0},
+	Bottom: {0, -1, 0},
+	Front:  {0, 0, 1},
+	Back:   {0, 0, -1},
+}
+
+func vertexAO(side1, side2, corner bool) int {
+	if side1 && side2 {
+		return 0
+	}
+
+	occluders := 0
+	for _, solid := range [3]bool{side1, side2, corner} {
+		if solid {
+			occluders++
+		}
+	}
+	return 3 - occluders
+}
+
+// faceLighting computes the occlusion level and the light of each vertex of the
+// face of the block at the section-local (x, y, z) pointing in direction, from
+// the blocks touching each vertex in the layer in front of the face, which may
+// lie in a neighbouring chunk. Without smooth lighting every vertex takes the
+// light of the block in front of the face.
+func (s *SectionSnapshot) faceLighting(x, y, z int, direction Direction) (ao [4]int, light [4]VoxelLight) {
+
+	normal := directionOffsets[direction]
+	front := [3]int{x + normal[0], y + normal[1], z + normal[2]}
+	frontLight := s.at(front[0], front[1], front[2]).Light
+
+	for i, corner := range faceCorners[direction] {
+		var side1, side2 [3]int
+		first := true
+		for axis := 0; axis < 3; axis++ {
+			if normal[axis] != 0 {
+				continue
+			}
+			if first {
+				side1[axis] = corner[axis]*2 - 1
+				first = false
+			} else {
+				side2[axis] = corner[axis]*2 - 1
+			}
+		}
+
+		b1 := s.at(front[0]+side1[0], front[1]+side1[1], front[2]+side1[2])
+		b2 := s.at(front[0]+side2[0], front[1]+side2[1], front[2]+side2[2])
+		bc := s.at(front[0]+side1[0]+side2[0], front[1]+side1[1]+side2[1], front[2]+side1[2]+side2[2])
+
+		ao[i] = vertexAO(b1.Solid, b2.Solid, bc.Solid)
+		light[i] = frontLight
+		if s.SmoothLighting {
+			// The corner can't leak light around two solid sides.
+			light[i] = averageLight(frontLight, b1, b2, bc, b1.Solid && b2.Solid)
+		}
+	}
+
+	return ao, light
+}
+
+// averageLight averages the light of the block in front of a face with the
+// open blocks among the ones sharing one of its vertices.
+func averageLight(front VoxelLight, side1, side2, corner *blockSnapshot, skipCorner bool) VoxelLight {
+	sky := int(front.Sky)
+	block := [3]int{int(front.Block[0]), int(front.Block[1]), int(front.Block[2])}
+	count := 1
+
+	add := func(b *blockSnapshot) {
+		if b.Solid {
+			return
+		}
+		sky += int(b.Light.Sky)
+		for i, level := range b.Light.Block {
+			block[i] += int(level)
+		}
+		count++
+	}
+	add(side1)
+	add(side2)
+	if !skipCorner {
+		add(corner)
+	}
+
+	result := VoxelLight{Sky: uint8((sky + count/2) / count)}
+	for i, level := range block {
+		result.Block[i] = uint8((level + count/2) / count)
+	}
+	return result
+}
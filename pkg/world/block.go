@@ -0,0 +1,101 @@
+package world
+
+import (
+	minemath "github.com/wmattei/minceraft/math"
+)
+
+var (
+	normalRight  = minemath.Vec3{1, 0, 0}
+	normalLeft   = minemath.Vec3{-1, 0, 0}
+	normalTop    = minemath.Vec3{0, 1, 0}
+	normalBottom = minemath.Vec3{0, -1, 0}
+	normalFront  = minemath.Vec3{0, 0, 1}
+	normalBack   = minemath.Vec3{0, 0, -1}
+)
+
+type Face struct {
+	Texture *Texture
+	Normal  minemath.Vec3
+}
+
+type Block struct {
+	Type        BlockType
+	Faces       [6]Face
+	Highlighted bool
+
+	Chunk *Chunk
+}
+
+type Direction int
+
+const (
+	Right Direction = iota
+	Left
+	Top
+	Bottom
+	Front
+	Back
+)
+
+type BlockType string
+
+const (
+	Air       BlockType = "air"
+	Grass     BlockType = "grass"
+	Torch     BlockType = "torch"
+	Lava      BlockType = "lava"
+	Glowstone BlockType = "glowstone"
+	Water     BlockType = "water"
+)
+
+func (b *Block) Update(w *Chunk) {}
+
+func newBlock(chunk *Chunk, blockType BlockType) *Block {
+	if blockType == Air {
+		return airBlock
+	}
+
+	side := chunk.World.textures[string(blockType)+string(SideText)]
+	top := chunk.World.textures[string(blockType)+string(TopText)]
+
+	return &Block{
+		Type:  blockType,
+		Chunk: chunk,
+		Faces: blockFaces(&side, &top),
+	}
+}
+
+func blockFaces(side, top *Texture) [6]Face {
+	return [6]Face{
+		{Texture: side, Normal: normalRight},
+		{Texture: side, Normal: normalLeft},
+		{Texture: top, Normal: normalTop},
+		{Texture: side, Normal: normalBottom},
+		{Texture: side, Normal: normalFront},
+		{Texture: side, Normal: normalBack},
+	}
+}
+
+// faceCorners holds, for every face direction, the block-relative corners of
+// its four vertices in winding order.
+var faceCorners = [6][4][3]int{
+	Right:  {{1, 0, 0}, {1, 1, 0}, {1, 1, 1}, {1, 0, 1}},
+	Left:   {{0, 0, 1}, {0, 1, 1}, {0, 1, 0}, {0, 0, 0}},
+	Top:    {{0, 1, 0}, {1, 1, 0}, {1, 1, 1}, {0, 1, 1}},
+	Bottom: {{0, 0, 0}, {1, 0, 0}, {1, 0, 1}, {0, 0, 1}},
+	Front:  {{0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 1, 1}},
+	Back:   {{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}},
+}
+
+var faceUVs = [6][4][2]int{
+	Right:  {{0, 1}, {0, 0}, {1, 0}, {1, 1}},
+	Left:   {{0, 1}, {0, 0}, {1, 0}, {1, 1}},
+	Top:    {{0, 1}, {0, 0}, {1, 0}, {1, 1}},
+	Bottom: {{0, 1}, {0, 0}, {1, 0}, {1, 1}},
+	Front:  {{1, 1}, {0, 1}, {0, 0}, {1, 0}},
+	Back:   {{1, 1}, {0, 1}, {0, 0}, {1, 0}},
+}
+
+func (b *Block) IsSolid() bool {
+	return b.Type != Air
+}
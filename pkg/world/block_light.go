@@ -0,0 +1,112 @@
+package world
+
+// Emission is the light given off by a block: a colour and the level of its
+// brightest channel.
+type Emission struct {
+	Color Color
+	Level int
+}
+
+// BLOCK_EMISSIONS lists the blocks that emit light.
+var BLOCK_EMISSIONS = map[BlockType]Emission{
+	Torch:     {Color: Color{255, 200, 120}, Level: 14},
+	Lava:      {Color: Color{255, 110, 40}, Level: 15},
+	Glowstone: {Color: Color{255, 230, 170}, Level: 15},
+}
+
+// Emission returns the block light levels the block emits on each channel.
+func (b *Block) Emission() VoxelLight {
+	if b.Type == Air {
+		return VoxelLight{}
+	}
+	emission, ok := BLOCK_EMISSIONS[b.Type]
+	if !ok {
+		return VoxelLight{}
+	}
+
+	level := min(max(emission.Level, 0), MAX_LIGHT)
+	channels := [3]int{emission.Color.R, emission.Color.G, emission.Color.B}
+	var light VoxelLight
+	for i, value := range channels {
+		light.Block[i] = uint8((level*min(max(value, 0), 255) + 127) / 255)
+	}
+	return light
+}
+
+func blockChannels(w *World) [3]*lightUpdate {
+	return [3]*lightUpdate{
+		newLightUpdate(w, redChannel),
+		newLightUpdate(w, greenChannel),
+		newLightUpdate(w, blueChannel),
+	}
+}
+
+// lightChunkBlocks spreads the light of the emitters of a freshly generated
+// chunk, along with the block light of its loaded neighbours.
+func (w *World) lightChunkBlocks(chunk *Chunk) {
+	updates := blockChannels(w)
+	originX, originZ := chunk.Position[0]*16, chunk.Position[1]*16
+
+	for x := 0; x < 16; x++ {
+		for z := 0; z < 16; z++ {
+			for y := 0; y < WORLD_HEIGHT; y++ {
+				emitted := chunk.At(x, y, z).Emission()
+				for i, u := range updates {
+					level := emitted.Block[i]
+					chunk.setLightLevel(u.channel, x, y, z, level)
+					if level > 1 {
+						u.queue = append(u.queue, lightNode{originX + x, y, originZ + z, level})
+					}
+				}
+			}
+		}
+	}
+
+	for i := 0; i < 16; i++ {
+		for _, border := range [4][2]int{{-1, i}, {16, i}, {i, -1}, {i, 16}} {
+			x, z := originX+border[0], originZ+border[1]
+			for y := 0; y < WORLD_HEIGHT; y++ {
+				for _, u := range updates {
+					if level, ok := u.level(x, y, z); ok && level > 1 {
+						u.queue = append(u.queue, lightNode{x, y, z, level})
+					}
+				}
+			}
+		}
+	}
+
+	for _, u := range updates {
+		u.propagate()
+	}
+}
+
+// updateBlockLight fixes the block light after the block at the world
+// position changed: the light it blocked or emitted is removed, then its own
+// emission and the light of its neighbours spread again.
+func (w *World) updateBlockLight(x, y, z int) {
+	for _, u := range blockChannels(w) {
+		level, ok := u.level(x, y, z)
+		if !ok {
+			return
+		}
+		if level > 0 {
+			u.set(x, y, z, 0)
+			u.removal = append(u.removal, lightNode{x, y, z, level})
+			u.unpropagate()
+		}
+
+		if emitted := u.emission(x, y, z); emitted > 0 {
+			u.set(x, y, z, emitted)
+			u.queue = append(u.queue, lightNode{x, y, z, emitted})
+		} else if !u.isOpaque(x, y, z) {
+			for _, offset := range directionOffsets {
+				nx, ny, nz := x+offset[0], y+offset[1], z+offset[2]
+				if level, ok := u.level(nx, ny, nz); ok && level > 0 {
+					u.queue = append(u.queue, lightNode{nx, ny, nz, level})
+				}
+			}
+		}
+
+		u.propagate()
+	}
+}
@@ -0,0 +1,215 @@
+package world
+
+import (
+	minemath "github.com/wmattei/minceraft/math"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+const WORLD_HEIGHT = 164
+const SEA_LEVEL = 64
+const MaxBlocksPerChunk = 16 * 16 * WORLD_HEIGHT
+
+type Chunk struct {
+	Blocks   [][][]*Block
+	Position [2]int
+
+	Sections [SECTIONS_PER_CHUNK]*ChunkSection
+	LOD      int
+
+	World *World
+}
+
+func (c *Chunk) RightNeighbor() *Chunk {
+	if len(c.World.chunks) <= 1 {
+		return nil
+	}
+
+	return c.World.chunks[[2]int{c.Position[0] + 1, c.Position[1]}]
+}
+
+func (c *Chunk) LeftNeighbor() *Chunk {
+	if len(c.World.chunks) <= 1 {
+		return nil
+	}
+	return c.World.chunks[[2]int{c.Position[0] - 1, c.Position[1]}]
+}
+
+func (c *Chunk) FrontNeighbor() *Chunk {
+	if len(c.World.chunks) <= 1 {
+		return nil
+	}
+	return c.World.chunks[[2]int{c.Position[0], c.Position[1] + 1}]
+}
+
+func (c *Chunk) BackNeighbor() *Chunk {
+	if len(c.World.chunks) <= 1 {
+		return nil
+	}
+	return c.World.chunks[[2]int{c.Position[0], c.Position[1] - 1}]
+}
+
+// Initialize schedules the chunk for meshing. Meshes are built in the
+// background and their GPU objects created when they are first uploaded.
+func (chunk *Chunk) Initialize() {
+	chunk.MarkAllDirty()
+}
+
+func (chunk *Chunk) Delete() {
+	for _, section := range chunk.Sections {
+		section.Delete(chunk.World.meshes)
+	}
+}
+
+// MarkDirty schedules a rebuild of the section containing y.
+func (chunk *Chunk) MarkDirty(y int) {
+	if y < 0 || y >= WORLD_HEIGHT {
+		return
+	}
+	chunk.Sections[sectionIndex(y)].NeedsUpdate = true
+}
+
+// SetLOD changes the level of detail the chunk is meshed at. Full detail
+// neighbours are remeshed as well so their skirts follow the change.
+func (chunk *Chunk) SetLOD(lod int) {
+	chunk.LOD = lod
+	chunk.MarkAllDirty()
+
+	for _, neighbor := range chunk.GetAllNeighbors() {
+		if neighbor != nil && neighbor.LOD == 0 {
+			neighbor.MarkAllDirty()
+		}
+	}
+}
+
+func (chunk *Chunk) MarkAllDirty() {
+	for _, section := range chunk.Sections {
+		section.NeedsUpdate = true
+	}
+}
+
+// chunkSides are the directions of the neighbours returned by GetAllNeighbors.
+var chunkSides = [4]Direction{Right, Left, Front, Back}
+
+func (chunk *Chunk) GetAllNeighbors() [4]*Chunk {
+	neighbors := [4]*Chunk{
+		chunk.RightNeighbor(),
+		chunk.LeftNeighbor(),
+		chunk.FrontNeighbor(),
+		chunk.BackNeighbor(),
+	}
+
+	return neighbors
+}
+
+// Bounds returns the world-space corners of the box holding the chunk.
+func (c *Chunk) Bounds() (minemath.Vec3, minemath.Vec3) {
+	x, z := float32(c.Position[0]*16), float32(c.Position[1]*16)
+	return minemath.Vec3{x, 0, z}, minemath.Vec3{x + 16, WORLD_HEIGHT, z + 16}
+}
+
+// SectionBounds returns the world-space corners of the box holding a section.
+func (c *Chunk) SectionBounds(section int) (minemath.Vec3, minemath.Vec3) {
+	min, max := c.Bounds()
+	min[1] = float32(section * SECTION_SIZE)
+	max[1] = min[1] + SECTION_SIZE
+	return min, max
+}
+
+// Submit queues the draws of the sections with the material. Occluded
+// sections are skipped and, with a frustum, those outside of it. It returns
+// the number of sections drawn, culled by the frustum and occluded.
+func (chunk *Chunk) Submit(renderer render.Renderer, material *render.Material, frustum *render.Frustum) (int, int, int) {
+	drawn, culled, occluded := 0, 0, 0
+	for i, section := range chunk.Sections {
+		if section.IsEmpty() || section.Geometry == nil {
+			continue
+		}
+		if section.Occluded {
+			occluded++
+			continue
+		}
+		if frustum != nil && frustum.TestAABB(chunk.SectionBounds(i)) == render.Outside {
+			culled++
+			continue
+		}
+		drawn++
+		renderer.Submit(section.Geometry.DrawCall(material))
+	}
+	return drawn, culled, occluded
+}
+
+var airBlock = &Block{Type: Air}
+
+func NewChunk(world *World, chunkX, chunkZ, size int) *Chunk {
+	// startedAt := time.Now()
+	// defer func() {
+	// 	elapsed := time.Since(startedAt)
+	// 	fmt.Printf("Generated chunk in %s\n", elapsed)
+	// }()
+	chunk := &Chunk{
+		Position: [2]int{chunkX, chunkZ},
+		Blocks:   make([][][]*Block, size),
+		World:    world,
+	}
+	for i := range chunk.Sections {
+		chunk.Sections[i] = &ChunkSection{Index: i, Visibility: ALL_VISIBLE}
+	}
+
+	grassSide := world.textures["grassside"]
+	grassTop := world.textures["grasstop"]
+
+	for x := 0; x < size; x++ {
+		chunk.Blocks[x] = make([][]*Block, size)
+		for z := 0; z < size; z++ {
+			chunk.Blocks[x][z] = make([]*Block, WORLD_HEIGHT)
+			chunkXPos := x + chunkX*16
+			chunkZPos := z + chunkZ*16
+
+			height := int(world.noise.GetHeight(chunkXPos, chunkZPos))
+			for y := 0; y < WORLD_HEIGHT; y++ {
+				if y > (height + SEA_LEVEL) {
+					chunk.Blocks[x][z][y] = airBlock
+					continue
+				}
+
+				block := &Block{}
+				block.Chunk = chunk
+				block.Type = Grass
+				block.Faces = blockFaces(&grassSide, &grassTop)
+
+				chunk.Blocks[x][z][y] = block
+				chunk.Sections[sectionIndex(y)].filledBlocks++
+
+			}
+		}
+	}
+
+	return chunk
+}
+
+// SetBlock replaces the block at the chunk-local position. Callers are
+// responsible for remeshing, see World.SetBlock.
+func (c *Chunk) SetBlock(x, y, z int, blockType BlockType) {
+	old := c.At(x, y, z)
+	if old == nil {
+		return
+	}
+
+	section := c.Sections[sectionIndex(y)]
+	if old.Type != Air {
+		section.filledBlocks--
+	}
+
+	block := newBlock(c, blockType)
+	if block.Type != Air {
+		section.filledBlocks++
+	}
+	c.Blocks[x][z][y] = block
+}
+
+func (c *Chunk) At(x, y, z int) *Block {
+	if x < 0 || x >= 16 || y < 0 || y >= WORLD_HEIGHT || z < 0 || z >= 16 {
+		return nil
+	}
+	return c.Blocks[x][z][y]
+}
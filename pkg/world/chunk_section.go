@@ -0,0 +1,70 @@
+package world
+
+import (
+	minemath "github.com/wmattei/minceraft/math"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+const SECTION_SIZE = 16
+const SECTIONS_PER_CHUNK = (WORLD_HEIGHT + SECTION_SIZE - 1) / SECTION_SIZE
+
+// ChunkSection is a 16 block high slice of a chunk column with its own mesh,
+// so a change only rebuilds the sections it touches.
+type ChunkSection struct {
+	Index int
+
+	// Geometry is the mesh of the section, uploaded to the meshes of the
+	// world, nil when it has no faces.
+	Geometry *render.Allocation
+
+	NeedsUpdate bool
+
+	// Visibility tells which faces see each other through the section, from
+	// its last mesh. Occluded is set on sections hidden from the camera, see
+	// cullOccluded.
+	Visibility SectionVisibility
+	Occluded   bool
+
+	// SkyLight holds the sky light level of every block, see skylight.go.
+	SkyLight [SECTION_VOLUME]uint8
+	// BlockLight holds the red, green and blue block light of every block,
+	// see block_light.go.
+	BlockLight [SECTION_VOLUME]uint16
+
+	// filledBlocks counts the blocks other than air.
+	filledBlocks int
+	meshVersion  uint64
+}
+
+func sectionIndex(y int) int {
+	return y / SECTION_SIZE
+}
+
+// IsEmpty reports whether the section only contains air. Empty sections are
+// never meshed or drawn.
+func (s *ChunkSection) IsEmpty() bool {
+	return s.filledBlocks == 0
+}
+
+func (s *ChunkSection) MinY() int {
+	return s.Index * SECTION_SIZE
+}
+
+// UpdateBuffers replaces the mesh of the section. Its vertices are relative
+// to origin, the corner of the chunk.
+func (s *ChunkSection) UpdateBuffers(meshes render.MeshUploader, mesh *SectionMesh, origin minemath.Vec3) {
+	s.Delete(meshes)
+	if len(mesh.Indices) == 0 {
+		return
+	}
+	s.Geometry = meshes.Upload(mesh.Vertices, mesh.Indices, origin)
+}
+
+func (s *ChunkSection) Delete(meshes render.MeshUploader) {
+	if s.Geometry == nil {
+		return
+	}
+
+	meshes.Free(s.Geometry)
+	s.Geometry = nil
+}
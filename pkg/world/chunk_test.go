@@ -0,0 +1,194 @@
+package world
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	minemath "github.com/wmattei/minceraft/math"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+// newTestWorld generates the chunks within radius of the origin, without
+// lighting them.
+func newTestWorld(radius int) *World {
+	world := &World{
+		chunks:   make(map[[2]int]*Chunk),
+		textures: make(map[string]Texture),
+	}
+	for x := -radius; x <= radius; x++ {
+		for z := -radius; z <= radius; z++ {
+			world.chunks[[2]int{x, z}] = NewChunk(world, x, z, 16)
+		}
+	}
+	return world
+}
+
+// Benchmark test for NewChunk function
+func BenchmarkNewChunk(b *testing.B) {
+	world := &World{
+		chunks:       make(map[[2]int]*Chunk),
+		textures:     make(map[string]Texture),
+		noise:        Noise{},
+		time:         NewWorldTime(DEFAULT_DAY_LENGTH),
+		activeChunk:  [2]int{0, 0},
+		renderDist:   8,
+		loadedChunks: make(map[[2]int]struct{}),
+	}
+
+	for x := -world.renderDist; x < world.renderDist; x++ {
+		for z := -world.renderDist; z < world.renderDist; z++ {
+			c := NewChunk(world, x, z, 16)
+			world.chunks[[2]int{x, z}] = c
+			world.loadedChunks[[2]int{x, z}] = struct{}{}
+		}
+	}
+
+	b.ResetTimer()
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_ = NewChunk(world, i, i, 16)
+	}
+}
+
+func TestMeshWorkerPoolMatchesSynchronousMesh(t *testing.T) {
+	world := newTestWorld(1)
+
+	chunk := world.chunks[[2]int{0, 0}]
+	pool := NewMeshWorkerPool(2, SECTIONS_PER_CHUNK)
+	for _, section := range chunk.Sections {
+		pool.Submit(chunk.Snapshot(section.Index))
+	}
+	pool.Stop()
+
+	meshes := make(map[int]*SectionMesh)
+	pool.Drain(time.Second, func(m *SectionMesh) { meshes[m.Section] = m })
+	if len(meshes) != SECTIONS_PER_CHUNK {
+		t.Fatalf("expected %d meshes from the worker pool, got %d", SECTIONS_PER_CHUNK, len(meshes))
+	}
+
+	total := 0
+	for _, section := range chunk.Sections {
+		mesh := meshes[section.Index]
+		sync := chunk.Snapshot(section.Index).BuildMesh()
+		if !slices.Equal(mesh.Vertices, sync.Vertices) || !slices.Equal(mesh.Indices, sync.Indices) {
+			t.Errorf("background mesh of section %d differs from the synchronous mesh", section.Index)
+		}
+		total += len(mesh.Indices)
+	}
+	if total == 0 {
+		t.Error("expected a non-empty mesh")
+	}
+}
+
+func TestSetBlockMarksAffectedSections(t *testing.T) {
+	world := newTestWorld(1)
+
+	world.SetBlock(0, 40, 5, Air)
+
+	for pos, chunk := range world.chunks {
+		for _, section := range chunk.Sections {
+			want := (pos == [2]int{0, 0} || pos == [2]int{-1, 0}) &&
+				(section.Index == sectionIndex(40) || section.Index == sectionIndex(39) || section.Index == sectionIndex(41))
+			if section.NeedsUpdate != want {
+				t.Errorf("chunk %v section %d: NeedsUpdate = %v, want %v", pos, section.Index, section.NeedsUpdate, want)
+			}
+		}
+	}
+	if block := world.GetBlock(0, 40, 5); block.IsSolid() {
+		t.Error("expected the block to be removed")
+	}
+}
+
+func TestLODMeshesAreCoarser(t *testing.T) {
+	world := newTestWorld(1)
+	chunk := world.chunks[[2]int{0, 0}]
+
+	faces := func(lod int) int {
+		chunk.LOD = lod
+		total := 0
+		for _, section := range chunk.Sections {
+			total += len(chunk.Snapshot(section.Index).BuildMesh().Indices) / 6
+		}
+		return total
+	}
+
+	previous := faces(0)
+	for lod := 1; lod <= MAX_LOD; lod++ {
+		current := faces(lod)
+		if current == 0 || current >= previous {
+			t.Errorf("LOD %d has %d faces, want fewer than %d", lod, current, previous)
+		}
+		previous = current
+	}
+}
+
+func sectionFaces(chunk *Chunk, section int) int {
+	snapshot := chunk.Snapshot(section)
+	defer snapshot.Release()
+	mesh := snapshot.BuildMesh()
+	defer mesh.Release()
+
+	return len(mesh.Indices) / 6
+}
+
+func TestCullingFollowsNeighbours(t *testing.T) {
+	world := newTestWorld(0)
+	chunk := world.chunks[[2]int{0, 0}]
+
+	section := sectionIndex(20)
+	if faces := sectionFaces(chunk, section); faces != 4*16*SECTION_SIZE {
+		t.Errorf("buried section without neighbours has %d faces, want its %d outer walls", faces, 4*16*SECTION_SIZE)
+	}
+
+	for _, pos := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+		world.chunks[pos] = NewChunk(world, pos[0], pos[1], 16)
+	}
+	if faces := sectionFaces(chunk, section); faces != 0 {
+		t.Errorf("buried section surrounded by chunks has %d faces, want 0", faces)
+	}
+
+	world.SetBlock(5, 20, 5, Air)
+	if faces := sectionFaces(chunk, section); faces != 6 {
+		t.Errorf("hollowed block exposes %d faces, want 6", faces)
+	}
+}
+
+func TestUnderwaterFollowsWaterBlocks(t *testing.T) {
+	world := newTestWorld(0)
+
+	world.SetBlock(5, 21, 5, Water)
+	world.SetBlock(5, 22, 5, Air)
+	if world.IsUnderwater([3]float32{5.5, 22.5, 5.5}) || !world.IsUnderwater([3]float32{5.5, 21.5, 5.5}) {
+		t.Error("expected only the water block to count as underwater")
+	}
+}
+
+func TestSubmitCullsSectionsOutsideTheFrustum(t *testing.T) {
+	chunk := &Chunk{Position: [2]int{0, 0}}
+	for i := range chunk.Sections {
+		chunk.Sections[i] = &ChunkSection{Index: i, filledBlocks: 1, Geometry: &render.Allocation{VAO: 1, FirstIndex: 6 * i, IndexCount: 6}}
+	}
+
+	// A narrow view of the side of the chunk, level with its lowest section.
+	view := minemath.LookAt(minemath.Vec3{8, 8, 40}, minemath.Vec3{8, 8, 0}, minemath.Vec3{0, 1, 0})
+	projection := minemath.GetPerspectiveProjectionMatrix(0.2, 1, 0.1, 100)
+	frustum := render.NewFrustum(nil)
+	frustum.UpdateFrustum(minemath.MultiplyMatrices(projection, view))
+
+	if got := frustum.TestAABB(chunk.Bounds()); got != render.Intersecting {
+		t.Fatalf("the chunk is %v, want it crossing the frustum", got)
+	}
+	renderer := render.NewRecordingRenderer()
+	drawn, culled, _ := chunk.Submit(renderer, render.NewMaterial("terrain", nil), frustum)
+	if drawn != 1 || culled != SECTIONS_PER_CHUNK-1 {
+		t.Errorf("drew %d and culled %d sections, want only the lowest one drawn", drawn, culled)
+	}
+
+	drawn, culled, _ = chunk.Submit(renderer, render.NewMaterial("terrain", nil), nil)
+	if drawn != SECTIONS_PER_CHUNK || culled != 0 {
+		t.Errorf("without a frustum drew %d and culled %d sections", drawn, culled)
+	}
+}
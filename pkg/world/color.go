@@ -1,6 +1,10 @@
-package main
+package world
 
-import minemath "github.com/wmattei/minceraft/math"
+import (
+	"math"
+
+	minemath "github.com/wmattei/minceraft/math"
+)
 
 var (
 	BLACK       = Color{0, 0, 0}
@@ -19,3 +23,11 @@ type Color struct {
 func (c Color) ToVec4() minemath.Vec4 {
 	return minemath.Vec4{float32(c.R) / 255, float32(c.G) / 255, float32(c.B) / 255, 1.0}
 }
+
+// Lerp blends linearly from c to other as t goes from 0 to 1.
+func (c Color) Lerp(other Color, t float32) Color {
+	mix := func(a, b int) int {
+		return int(math.Round(float64(float32(a) + (float32(b)-float32(a))*t)))
+	}
+	return Color{mix(c.R, other.R), mix(c.G, other.G), mix(c.B, other.B)}
+}
@@ -0,0 +1,482 @@
+package world
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	minemath "github.com/wmattei/minceraft/math"
+)
+
+// aoFactors mirrors the ambient occlusion curve of the chunk vertex shader so
+// exported vertex colours match what the game renders.
+var aoFactors = [4]float32{0.45, 0.65, 0.82, 1.0}
+
+// ExportMesh is the output of the chunk mesher decoded into world space, ready
+// to be written to interchange formats.
+type ExportMesh struct {
+	Positions []minemath.Vec3
+	Normals   []minemath.Vec3
+	UVs       []minemath.Vec2
+	Colors    []minemath.Vec4
+
+	// Groups holds the triangle indices of every texture layer.
+	Groups map[int][]uint32
+}
+
+// ChunksInRegion returns the loaded chunks whose positions lie within the
+// inclusive chunk coordinate range.
+func (w *World) ChunksInRegion(min, max [2]int) []*Chunk {
+	var chunks []*Chunk
+	for x := min[0]; x <= max[0]; x++ {
+		for z := min[1]; z <= max[1]; z++ {
+			if chunk, ok := w.chunks[[2]int{x, z}]; ok {
+				chunks = append(chunks, chunk)
+			}
+		}
+	}
+	return chunks
+}
+
+// BuildExportMesh meshes the chunks at full detail on the calling goroutine and
+// decodes the packed vertices. It doesn't need a GL context.
+func (w *World) BuildExportMesh(chunks []*Chunk) *ExportMesh {
+	mesh := &ExportMesh{Groups: make(map[int][]uint32)}
+	tints := w.textureTints()
+	highlight := w.highlight.ToVec4()
+
+	for _, chunk := range chunks {
+		offset := minemath.Vec3{float32(chunk.Position[0] * 16), 0, float32(chunk.Position[1] * 16)}
+
+		for _, section := range chunk.Sections {
+			if section.IsEmpty() {
+				continue
+			}
+
+			snapshot := chunk.Snapshot(section.Index)
+			snapshot.LOD, snapshot.skirts = 0, 0
+			sectionMesh := snapshot.BuildMesh()
+			snapshot.Release()
+
+			base := uint32(len(mesh.Positions))
+			for i := 0; i < len(sectionMesh.Vertices); i += VERTEX_WORDS {
+				v := unpackVertex(sectionMesh.Vertices[i], sectionMesh.Vertices[i+1])
+				normal := directionOffsets[v.Normal]
+
+				tint := tints[v.Layer]
+				if v.Highlighted {
+					tint = highlight
+				}
+				normalVec := minemath.Vec3{float32(normal[0]), float32(normal[1]), float32(normal[2])}
+				light := VoxelLight{
+					Sky:   uint8(v.Light),
+					Block: [3]uint8{uint8(v.BlockLight[0]), uint8(v.BlockLight[1]), uint8(v.BlockLight[2])},
+				}
+				shade := light.Brightness(w.lighting.Shade(v.Normal, w.time))
+				ao := aoFactors[v.AO]
+
+				mesh.Positions = append(mesh.Positions, minemath.Add(offset, minemath.Vec3{float32(v.X), float32(v.Y), float32(v.Z)}))
+				mesh.Normals = append(mesh.Normals, normalVec)
+				mesh.UVs = append(mesh.UVs, minemath.Vec2{float32(v.U), float32(v.V)})
+				mesh.Colors = append(mesh.Colors, minemath.Vec4{tint[0] * shade[0] * ao, tint[1] * shade[1] * ao, tint[2] * shade[2] * ao, 1})
+			}
+
+			// Every quad references a single layer, so the first vertex of a
+			// triangle tells which group it belongs to.
+			for i := 0; i < len(sectionMesh.Indices); i += 3 {
+				layer := int(sectionMesh.Vertices[sectionMesh.Indices[i]*VERTEX_WORDS+1] & 255)
+				for _, index := range sectionMesh.Indices[i : i+3] {
+					mesh.Groups[layer] = append(mesh.Groups[layer], base+index)
+				}
+			}
+			sectionMesh.Release()
+		}
+	}
+
+	return mesh
+}
+
+func (w *World) textureTints() map[int]minemath.Vec4 {
+	tints := make(map[int]minemath.Vec4, len(w.textures))
+	for _, texture := range w.textures {
+		tint := WHITE.ToVec4()
+		if texture.Color != nil {
+			tint = texture.Color.ToVec4()
+		}
+		tints[texture.Index] = tint
+	}
+	return tints
+}
+
+// EXPORT_TEXTURE_DIR is the directory, next to the exported file, the
+// textures are copied to.
+const EXPORT_TEXTURE_DIR = "textures"
+
+// ExportMaterial describes the texture of a layer. Path is relative to the
+// exported file and Source is the image in the resource packs copied there.
+type ExportMaterial struct {
+	Layer  int
+	Name   string
+	Path   string
+	Source string
+}
+
+func (w *World) ExportMaterials(mesh *ExportMesh) []ExportMaterial {
+	byLayer := make(map[int]ExportMaterial, len(w.textures))
+	for name, texture := range w.textures {
+		material := ExportMaterial{Layer: texture.Index, Name: name}
+		if !texture.Removed {
+			material.Source = filepath.ToSlash(texture.Path)
+			material.Path = EXPORT_TEXTURE_DIR + "/" + spriteName(texture.Path) + filepath.Ext(texture.Path)
+		}
+		byLayer[texture.Index] = material
+	}
+
+	var materials []ExportMaterial
+	for layer := range mesh.Groups {
+		material, ok := byLayer[layer]
+		if !ok {
+			material = ExportMaterial{Layer: layer, Name: fmt.Sprintf("layer%d", layer)}
+		}
+		materials = append(materials, material)
+	}
+	sort.Slice(materials, func(i, j int) bool { return materials[i].Layer < materials[j].Layer })
+
+	return materials
+}
+
+// ExportChunks writes the mesh of the chunks to path. The format is picked from
+// the extension: ".obj" writes Wavefront OBJ with a sibling ".mtl" file and
+// ".glb" writes binary glTF 2.0. The textures are read from the resource packs
+// and copied next to it, see EXPORT_TEXTURE_DIR.
+func (w *World) ExportChunks(chunks []*Chunk, path string) error {
+	mesh := w.BuildExportMesh(chunks)
+	if len(mesh.Positions) == 0 {
+		return fmt.Errorf("nothing to export")
+	}
+
+	outputDir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	materials := w.ExportMaterials(mesh)
+	if err := w.copyExportTextures(materials, outputDir); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".obj":
+		mtlPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".mtl"
+		if err := writeFile(mtlPath, func(out io.Writer) error { return WriteMTL(out, materials) }); err != nil {
+			return err
+		}
+		return writeFile(path, func(out io.Writer) error {
+			return WriteOBJ(out, mesh, materials, filepath.Base(mtlPath))
+		})
+	case ".glb":
+		return writeFile(path, func(out io.Writer) error { return WriteGLB(out, mesh, materials) })
+	default:
+		return fmt.Errorf("unsupported export format %q", filepath.Ext(path))
+	}
+}
+
+// copyExportTextures copies the images of the materials from the resource
+// packs to their path below outputDir.
+func (w *World) copyExportTextures(materials []ExportMaterial, outputDir string) error {
+	for _, material := range materials {
+		if material.Source == "" {
+			continue
+		}
+		data, err := fs.ReadFile(w.assets, material.Source)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(outputDir, filepath.FromSlash(material.Path))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(file)
+	if err := write(out); err != nil {
+		file.Close()
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func WriteMTL(out io.Writer, materials []ExportMaterial) error {
+	for _, material := range materials {
+		if _, err := fmt.Fprintf(out, "newmtl %s\nKd 1 1 1\nillum 0\n", material.Name); err != nil {
+			return err
+		}
+		if material.Path != "" {
+			if _, err := fmt.Fprintf(out, "map_Kd %s\n", material.Path); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteOBJ writes the mesh as Wavefront OBJ. Vertex colours use the common
+// "v x y z r g b" extension understood by Blender.
+func WriteOBJ(out io.Writer, mesh *ExportMesh, materials []ExportMaterial, mtlName string) error {
+	b := bufio.NewWriter(out)
+
+	fmt.Fprintf(b, "mtllib %s\n", mtlName)
+	for i, p := range mesh.Positions {
+		c := mesh.Colors[i]
+		fmt.Fprintf(b, "v %g %g %g %g %g %g\n", p[0], p[1], p[2], c[0], c[1], c[2])
+	}
+	for _, uv := range mesh.UVs {
+		// OBJ texture coordinates start at the bottom of the image.
+		fmt.Fprintf(b, "vt %g %g\n", uv[0], 1-uv[1])
+	}
+	for _, n := range mesh.Normals {
+		fmt.Fprintf(b, "vn %g %g %g\n", n[0], n[1], n[2])
+	}
+
+	for _, material := range materials {
+		fmt.Fprintf(b, "usemtl %s\n", material.Name)
+		indices := mesh.Groups[material.Layer]
+		for i := 0; i < len(indices); i += 3 {
+			a, c, d := indices[i]+1, indices[i+1]+1, indices[i+2]+1
+			fmt.Fprintf(b, "f %d/%d/%d %d/%d/%d %d/%d/%d\n", a, a, a, c, c, c, d, d, d)
+		}
+	}
+
+	return b.Flush()
+}
+
+type gltfDocument struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Materials   []gltfMaterial   `json:"materials,omitempty"`
+	Textures    []gltfTexture    `json:"textures,omitempty"`
+	Images      []gltfImage      `json:"images,omitempty"`
+	Samplers    []gltfSampler    `json:"samplers,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Mesh int `json:"mesh"`
+}
+
+type gltfMesh struct {
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Material   *int           `json:"material,omitempty"`
+}
+
+type gltfMaterial struct {
+	Name                 string                   `json:"name"`
+	PBRMetallicRoughness gltfPBRMetallicRoughness `json:"pbrMetallicRoughness"`
+	DoubleSided          bool                     `json:"doubleSided"`
+}
+
+type gltfPBRMetallicRoughness struct {
+	BaseColorTexture *gltfTextureInfo `json:"baseColorTexture,omitempty"`
+	MetallicFactor   float32          `json:"metallicFactor"`
+	RoughnessFactor  float32          `json:"roughnessFactor"`
+}
+
+type gltfTextureInfo struct {
+	Index int `json:"index"`
+}
+
+type gltfTexture struct {
+	Sampler int `json:"sampler"`
+	Source  int `json:"source"`
+}
+
+type gltfImage struct {
+	URI string `json:"uri"`
+}
+
+type gltfSampler struct {
+	MagFilter int `json:"magFilter"`
+	MinFilter int `json:"minFilter"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target,omitempty"`
+}
+
+type gltfBuffer struct {
+	ByteLength int `json:"byteLength"`
+}
+
+const (
+	gltfFloat        = 5126
+	gltfUnsignedInt  = 5125
+	gltfArrayBuffer  = 34962
+	gltfElementArray = 34963
+	gltfNearest      = 9728
+	gltfMagicHeader  = 0x46546C67
+	gltfChunkJSON    = 0x4E4F534A
+	gltfChunkBIN     = 0x004E4942
+)
+
+// WriteGLB writes the mesh as a binary glTF 2.0 file with one primitive per
+// texture layer sharing the vertex attributes.
+func WriteGLB(out io.Writer, mesh *ExportMesh, materials []ExportMaterial) error {
+	doc := gltfDocument{
+		Asset:   gltfAsset{Version: "2.0", Generator: "minceraft"},
+		Scenes:  []gltfScene{{Nodes: []int{0}}},
+		Nodes:   []gltfNode{{Mesh: 0}},
+		Buffers: []gltfBuffer{{}},
+	}
+	var bin bytes.Buffer
+
+	addView := func(data any, target int) int {
+		for bin.Len()%4 != 0 {
+			bin.WriteByte(0)
+		}
+		offset := bin.Len()
+		binary.Write(&bin, binary.LittleEndian, data)
+		doc.BufferViews = append(doc.BufferViews, gltfBufferView{ByteOffset: offset, ByteLength: bin.Len() - offset, Target: target})
+		return len(doc.BufferViews) - 1
+	}
+	addAccessor := func(view, componentType, count int, kind string) int {
+		doc.Accessors = append(doc.Accessors, gltfAccessor{BufferView: view, ComponentType: componentType, Count: count, Type: kind})
+		return len(doc.Accessors) - 1
+	}
+
+	count := len(mesh.Positions)
+	position := addAccessor(addView(mesh.Positions, gltfArrayBuffer), gltfFloat, count, "VEC3")
+	if count > 0 {
+		min := [3]float32{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+		max := [3]float32{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+		for _, p := range mesh.Positions {
+			for axis := 0; axis < 3; axis++ {
+				min[axis] = float32(math.Min(float64(min[axis]), float64(p[axis])))
+				max[axis] = float32(math.Max(float64(max[axis]), float64(p[axis])))
+			}
+		}
+		doc.Accessors[position].Min = min[:]
+		doc.Accessors[position].Max = max[:]
+	}
+	attributes := map[string]int{
+		"POSITION":   position,
+		"NORMAL":     addAccessor(addView(mesh.Normals, gltfArrayBuffer), gltfFloat, count, "VEC3"),
+		"TEXCOORD_0": addAccessor(addView(mesh.UVs, gltfArrayBuffer), gltfFloat, count, "VEC2"),
+		"COLOR_0":    addAccessor(addView(mesh.Colors, gltfArrayBuffer), gltfFloat, count, "VEC4"),
+	}
+
+	var primitives []gltfPrimitive
+	for _, material := range materials {
+		indices := mesh.Groups[material.Layer]
+		primitive := gltfPrimitive{
+			Attributes: attributes,
+			Indices:    addAccessor(addView(indices, gltfElementArray), gltfUnsignedInt, len(indices), "SCALAR"),
+		}
+
+		// The mesher doesn't keep a consistent winding, faces are never culled.
+		gltfMat := gltfMaterial{
+			Name:                 material.Name,
+			PBRMetallicRoughness: gltfPBRMetallicRoughness{MetallicFactor: 0, RoughnessFactor: 1},
+			DoubleSided:          true,
+		}
+		if material.Path != "" {
+			if len(doc.Samplers) == 0 {
+				doc.Samplers = []gltfSampler{{MagFilter: gltfNearest, MinFilter: gltfNearest}}
+			}
+			doc.Images = append(doc.Images, gltfImage{URI: material.Path})
+			doc.Textures = append(doc.Textures, gltfTexture{Sampler: 0, Source: len(doc.Images) - 1})
+			gltfMat.PBRMetallicRoughness.BaseColorTexture = &gltfTextureInfo{Index: len(doc.Textures) - 1}
+		}
+		doc.Materials = append(doc.Materials, gltfMat)
+		materialIndex := len(doc.Materials) - 1
+		primitive.Material = &materialIndex
+
+		primitives = append(primitives, primitive)
+	}
+	doc.Meshes = []gltfMesh{{Primitives: primitives}}
+
+	for bin.Len()%4 != 0 {
+		bin.WriteByte(0)
+	}
+	doc.Buffers[0].ByteLength = bin.Len()
+
+	jsonChunk, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	for len(jsonChunk)%4 != 0 {
+		jsonChunk = append(jsonChunk, ' ')
+	}
+
+	header := []uint32{
+		gltfMagicHeader, 2, uint32(12 + 8 + len(jsonChunk) + 8 + bin.Len()),
+		uint32(len(jsonChunk)), gltfChunkJSON,
+	}
+	if err := binary.Write(out, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if _, err := out.Write(jsonChunk); err != nil {
+		return err
+	}
+	if err := binary.Write(out, binary.LittleEndian, []uint32{uint32(bin.Len()), gltfChunkBIN}); err != nil {
+		return err
+	}
+	_, err = out.Write(bin.Bytes())
+	return err
+}
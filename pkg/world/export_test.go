@@ -0,0 +1,100 @@
+package world
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newExportTestWorld() *World {
+	world := newWorld(1, map[string]Texture{
+		"grassside": {Path: "assets/textures/block/grass_block_side.png", Index: 0},
+		"grasstop":  {Path: "assets/textures/block/grass_block_top.png", Index: 1, Color: &Color{102, 240, 84}},
+	})
+	return world
+}
+
+func TestWriteOBJ(t *testing.T) {
+	world := newExportTestWorld()
+	defer world.Close()
+
+	mesh := world.BuildExportMesh(world.ChunksInRegion([2]int{0, 0}, [2]int{0, 0}))
+	materials := world.ExportMaterials(mesh)
+
+	var out bytes.Buffer
+	if err := WriteOBJ(&out, mesh, materials, "terrain.mtl"); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+	for _, line := range strings.Split(out.String(), "\n") {
+		counts[strings.SplitN(line, " ", 2)[0]]++
+	}
+	if counts["v"] != len(mesh.Positions) || counts["vt"] != len(mesh.Positions) || counts["vn"] != len(mesh.Positions) {
+		t.Errorf("unexpected vertex counts %v for %d vertices", counts, len(mesh.Positions))
+	}
+	triangles := 0
+	for _, indices := range mesh.Groups {
+		triangles += len(indices) / 3
+	}
+	if counts["f"] != triangles || triangles == 0 {
+		t.Errorf("got %d faces, want %d", counts["f"], triangles)
+	}
+	if counts["usemtl"] != len(materials) {
+		t.Errorf("got %d materials, want %d", counts["usemtl"], len(materials))
+	}
+}
+
+func TestWriteGLB(t *testing.T) {
+	world := newExportTestWorld()
+	defer world.Close()
+
+	mesh := world.BuildExportMesh(world.ChunksInRegion([2]int{-1, -1}, [2]int{0, 0}))
+	materials := world.ExportMaterials(mesh)
+
+	var out bytes.Buffer
+	if err := WriteGLB(&out, mesh, materials); err != nil {
+		t.Fatal(err)
+	}
+
+	data := out.Bytes()
+	if binary.LittleEndian.Uint32(data[0:]) != gltfMagicHeader {
+		t.Fatal("missing glTF magic")
+	}
+	if length := binary.LittleEndian.Uint32(data[8:]); int(length) != len(data) {
+		t.Errorf("header length %d, file length %d", length, len(data))
+	}
+	jsonLength := binary.LittleEndian.Uint32(data[12:])
+	if jsonLength%4 != 0 {
+		t.Errorf("JSON chunk length %d is not aligned", jsonLength)
+	}
+	if !bytes.Contains(data[20:20+jsonLength], []byte(`"uri":"textures/block/grass_block_top.png"`)) {
+		t.Error("expected the texture to be referenced")
+	}
+	binHeader := 20 + jsonLength
+	if binary.LittleEndian.Uint32(data[binHeader+4:]) != gltfChunkBIN {
+		t.Error("missing binary chunk")
+	}
+}
+
+func TestExportCopiesTexturesFromThePacks(t *testing.T) {
+	world := newExportTestWorld()
+	defer world.Close()
+	world.assets = fstest.MapFS{
+		"assets/textures/block/grass_block_side.png": {Data: []byte("side")},
+		"assets/textures/block/grass_block_top.png":  {Data: []byte("top from a pack")},
+	}
+
+	dir := t.TempDir()
+	if err := world.ExportChunks(world.ChunksInRegion([2]int{0, 0}, [2]int{0, 0}), filepath.Join(dir, "terrain.obj")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, EXPORT_TEXTURE_DIR, "block", "grass_block_top.png"))
+	if err != nil || string(data) != "top from a pack" {
+		t.Errorf("the exported texture reads %q, %v", data, err)
+	}
+}
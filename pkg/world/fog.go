@@ -0,0 +1,92 @@
+package world
+
+import (
+	"math"
+
+	minemath "github.com/wmattei/minceraft/math"
+)
+
+type FogMode int
+
+const (
+	FogNone FogMode = iota
+	FogLinear
+	FogExponential
+)
+
+// FOG_END_VISIBILITY is how much of a surface still shows through exponential
+// fog at its end distance.
+const FOG_END_VISIBILITY = 0.02
+
+// FogSettings fade the terrain into the sky colour before it reaches the
+// edge of the loaded chunks, so they don't pop in.
+type FogSettings struct {
+	Mode FogMode
+	// Start is where linear fog begins, as a fraction of the end distance.
+	Start float32
+
+	// Underwater fog replaces the distance fog while the camera is in water.
+	UnderwaterColor    Color
+	UnderwaterDistance float32
+}
+
+func DefaultFogSettings() FogSettings {
+	return FogSettings{
+		Mode:               FogLinear,
+		Start:              0.6,
+		UnderwaterColor:    Color{30, 60, 120},
+		UnderwaterDistance: 24,
+	}
+}
+
+// fogEnd is where the fog hides the terrain completely: the nearest distance
+// at which chunks can be missing.
+func (w *World) fogEnd() float32 {
+	return float32(max(w.renderDist-1, 1) * 16)
+}
+
+// IsUnderwater reports whether the position lies inside a water block.
+func (w *World) IsUnderwater(position [3]float32) bool {
+	x, y, z := math.Floor(float64(position[0])), math.Floor(float64(position[1])), math.Floor(float64(position[2]))
+	block := w.GetBlock(int(x), int(y), int(z))
+	return block != nil && block.Type == Water
+}
+
+// exponentialFogDensity returns the density of squared exponential fog that
+// leaves FOG_END_VISIBILITY of a surface visible at the end distance.
+func exponentialFogDensity(end float32) float32 {
+	return float32(math.Sqrt(-math.Log(FOG_END_VISIBILITY))) / end
+}
+
+// FogUniforms are the values of the fog uniforms of the chunk program.
+type FogUniforms struct {
+	Mode    FogMode
+	Start   float32
+	End     float32
+	Density float32
+	Color   minemath.Vec3
+}
+
+// Fog returns the fog of the current frame, seen from the camera position.
+func (w *World) Fog(camera minemath.Vec3) FogUniforms {
+	mode, start, end := w.fog.Mode, w.fog.Start*w.fogEnd(), w.fogEnd()
+	_, color := w.time.SkyColors()
+	if w.IsUnderwater(camera) {
+		mode, start, end = FogExponential, 0, w.fog.UnderwaterDistance
+		color = w.fog.UnderwaterColor
+	}
+
+	fogColor := color.ToVec4()
+	return FogUniforms{
+		Mode:    mode,
+		Start:   start,
+		End:     end,
+		Density: exponentialFogDensity(end),
+		Color:   minemath.Vec3{fogColor[0], fogColor[1], fogColor[2]},
+	}
+}
+
+// SetFogSettings replaces the fog of the world.
+func (w *World) SetFogSettings(settings FogSettings) {
+	w.fog = settings
+}
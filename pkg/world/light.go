@@ -0,0 +1,88 @@
+package world
+
+import (
+	"math"
+
+	minemath "github.com/wmattei/minceraft/math"
+)
+
+// DEFAULT_DAY_LENGTH is how long a full day lasts, in seconds.
+const DEFAULT_DAY_LENGTH = 20 * 60
+
+// SUN_TILT tilts the path of the sun towards the south so its light never hits
+// the faces of a block evenly.
+const SUN_TILT = 0.4
+
+// MOON_BRIGHTNESS is the sky brightness of a full night, relative to noon.
+const MOON_BRIGHTNESS = 0.2
+
+var (
+	DAY_ZENITH     = Color{70, 130, 225}
+	DAY_HORIZON    = Color{170, 205, 240}
+	SUNSET_ZENITH  = Color{60, 75, 140}
+	SUNSET_HORIZON = Color{240, 140, 80}
+	NIGHT_ZENITH   = Color{4, 6, 18}
+	NIGHT_HORIZON  = Color{14, 20, 40}
+)
+
+// WorldTime is the time of day, which runs from 0 to 1: 0 is sunrise, 0.25
+// noon, 0.5 sunset and 0.75 midnight.
+type WorldTime struct {
+	Time      float64
+	DayLength float64
+}
+
+func NewWorldTime(dayLength float64) WorldTime {
+	return WorldTime{Time: 0.1, DayLength: dayLength}
+}
+
+// Advance moves the time forward by dt seconds.
+func (t *WorldTime) Advance(dt float64) {
+	if t.DayLength <= 0 {
+		return
+	}
+	_, t.Time = math.Modf(t.Time + dt/t.DayLength)
+}
+
+// SunDirection points from the ground towards the sun, which rises in the east
+// (+X) and sets in the west.
+func (t WorldTime) SunDirection() minemath.Vec3 {
+	angle := t.Time * 2 * math.Pi
+	return minemath.Normalize(minemath.Vec3{float32(math.Cos(angle)), float32(math.Sin(angle)), SUN_TILT})
+}
+
+// MoonDirection points towards the moon, always opposite the sun.
+func (t WorldTime) MoonDirection() minemath.Vec3 {
+	return t.SunDirection().Negate()
+}
+
+// LightDirection is the direction of the sun by day and of the moon at night.
+func (t WorldTime) LightDirection() minemath.Vec3 {
+	if sun := t.SunDirection(); sun[1] >= 0 {
+		return sun
+	}
+	return t.MoonDirection()
+}
+
+// SkyBrightness scales the sky light, fading from 1 by day to MOON_BRIGHTNESS
+// at night through the twilight.
+func (t WorldTime) SkyBrightness() float32 {
+	daylight := smoothstep(-0.2, 0.25, t.SunDirection()[1])
+	return MOON_BRIGHTNESS + (1-MOON_BRIGHTNESS)*daylight
+}
+
+// SkyColors returns the colours of the sky straight up and at the horizon.
+func (t WorldTime) SkyColors() (zenith, horizon Color) {
+	elevation := t.SunDirection()[1]
+	if elevation >= 0 {
+		day := smoothstep(0, 0.3, elevation)
+		return SUNSET_ZENITH.Lerp(DAY_ZENITH, day), SUNSET_HORIZON.Lerp(DAY_HORIZON, day)
+	}
+	night := smoothstep(0, 0.25, -elevation)
+	return SUNSET_ZENITH.Lerp(NIGHT_ZENITH, night), SUNSET_HORIZON.Lerp(NIGHT_HORIZON, night)
+}
+
+func smoothstep(edge0, edge1, x float32) float32 {
+	t := min(max((x-edge0)/(edge1-edge0), 0), 1)
+	return t * t * (3 - 2*t)
+}
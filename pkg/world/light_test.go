@@ -0,0 +1,54 @@
+package world
+
+import (
+	"testing"
+
+	minemath "github.com/wmattei/minceraft/math"
+)
+
+func TestWorldTimeFollowsTheSun(t *testing.T) {
+	noon := WorldTime{Time: 0.25}
+	midnight := WorldTime{Time: 0.75}
+
+	if sun := noon.SunDirection(); sun[1] < 0.9 {
+		t.Errorf("sun at noon = %v, want it overhead", sun)
+	}
+	if light := midnight.LightDirection(); light[1] < 0.9 {
+		t.Errorf("light at midnight = %v, want the moon overhead", light)
+	}
+	if noon.SkyBrightness() != 1 || midnight.SkyBrightness() != MOON_BRIGHTNESS {
+		t.Errorf("sky brightness = %v at noon and %v at midnight", noon.SkyBrightness(), midnight.SkyBrightness())
+	}
+	if zenith, _ := midnight.SkyColors(); zenith != NIGHT_ZENITH {
+		t.Errorf("zenith at midnight = %v, want %v", zenith, NIGHT_ZENITH)
+	}
+
+	time := WorldTime{Time: 0.9, DayLength: 100}
+	time.Advance(30)
+	if time.Time < 0.199 || time.Time > 0.201 {
+		t.Errorf("time after wrapping around = %v, want 0.2", time.Time)
+	}
+}
+
+func TestLightingSettingsShade(t *testing.T) {
+	noon := WorldTime{Time: 0.25}
+
+	settings := DefaultLightingSettings()
+	top, bottom := settings.Shade(Top, noon), settings.Shade(Bottom, noon)
+	if top[1] <= bottom[1] {
+		t.Errorf("top face shade %v should be brighter than bottom %v at noon", top, bottom)
+	}
+
+	flat := LightingSettings{
+		Ambient:            WHITE,
+		AmbientIntensity:   1,
+		FaceShading:        true,
+		FaceShadingFactors: DefaultLightingSettings().FaceShadingFactors,
+	}
+	for direction := Right; direction <= Back; direction++ {
+		want := flat.FaceShadingFactors[direction]
+		if shade := flat.Shade(direction, noon); shade != (minemath.Vec3{want, want, want}) {
+			t.Errorf("face shading of direction %d = %v, want %v", direction, shade, want)
+		}
+	}
+}
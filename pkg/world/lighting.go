@@ -0,0 +1,248 @@
+package world
+
+import (
+	"math"
+
+	minemath "github.com/wmattei/minceraft/math"
+)
+
+const SECTION_VOLUME = SECTION_SIZE * 16 * 16
+
+// lightChannel is one of the light values every block stores: its sky light
+// or one colour channel of its block light.
+type lightChannel int
+
+const (
+	skyChannel lightChannel = iota
+	redChannel
+	greenChannel
+	blueChannel
+)
+
+// VoxelLight is the light reaching a block, levels range from 0 to MAX_LIGHT.
+type VoxelLight struct {
+	Sky   uint8
+	Block [3]uint8
+}
+
+var fullSkyLight = VoxelLight{Sky: MAX_LIGHT}
+
+// lightBrightness maps a light level to a brightness factor. It mirrors the
+// light curve of the chunk vertex shader.
+func lightBrightness(level int) float32 {
+	return float32(math.Pow(0.8, float64(MAX_LIGHT-level)))
+}
+
+// Brightness combines sky and block light the way the chunk fragment shader
+// does, the sky light being tinted by the shade of the face first.
+func (l VoxelLight) Brightness(shade minemath.Vec3) [3]float32 {
+	sky := lightBrightness(int(l.Sky))
+	var result [3]float32
+	for i, level := range l.Block {
+		result[i] = max(sky*shade[i], lightBrightness(int(level)))
+	}
+	return result
+}
+
+func sectionLightIndex(x, y, z int) int {
+	return (y%SECTION_SIZE)*256 + z*16 + x
+}
+
+// Light returns the light of every channel at the chunk-local position.
+func (c *Chunk) Light(x, y, z int) VoxelLight {
+	light := VoxelLight{Sky: c.lightLevel(skyChannel, x, y, z)}
+	for i := range light.Block {
+		light.Block[i] = c.lightLevel(redChannel+lightChannel(i), x, y, z)
+	}
+	return light
+}
+
+func (c *Chunk) lightLevel(channel lightChannel, x, y, z int) uint8 {
+	if channel == skyChannel {
+		return c.SkyLight(x, y, z)
+	}
+	if y < 0 || y >= WORLD_HEIGHT {
+		return 0
+	}
+	packed := c.Sections[sectionIndex(y)].BlockLight[sectionLightIndex(x, y, z)]
+	return uint8(packed >> blockLightShift(channel) & 15)
+}
+
+func (c *Chunk) setLightLevel(channel lightChannel, x, y, z int, level uint8) {
+	if channel == skyChannel {
+		c.setSkyLight(x, y, z, level)
+		return
+	}
+	packed := &c.Sections[sectionIndex(y)].BlockLight[sectionLightIndex(x, y, z)]
+	shift := blockLightShift(channel)
+	*packed = *packed&^(15<<shift) | uint16(level)<<shift
+}
+
+// Block light is packed as red << 8 | green << 4 | blue.
+func blockLightShift(channel lightChannel) int {
+	return (int(blueChannel) - int(channel)) * 4
+}
+
+type lightNode struct {
+	x, y, z int
+	level   uint8
+}
+
+// lightUpdate flood fills one light channel through the world, across chunk
+// borders, marking the sections whose meshes see a change.
+type lightUpdate struct {
+	world   *World
+	channel lightChannel
+	chunk   *Chunk // last chunk looked up, most accesses hit it again
+
+	queue   []lightNode
+	removal []lightNode
+}
+
+func newLightUpdate(w *World, channel lightChannel) *lightUpdate {
+	return &lightUpdate{world: w, channel: channel}
+}
+
+func (u *lightUpdate) locate(x, z int) (*Chunk, int, int) {
+	chunkX, chunkZ, posX, posZ := worldToChunkCoords(x, z)
+	if u.chunk == nil || u.chunk.Position != [2]int{chunkX, chunkZ} {
+		u.chunk = u.world.chunks[[2]int{chunkX, chunkZ}]
+	}
+	return u.chunk, posX, posZ
+}
+
+// level returns the light at the world position and whether light can be
+// stored there at all.
+func (u *lightUpdate) level(x, y, z int) (uint8, bool) {
+	if y < 0 || y >= WORLD_HEIGHT {
+		return 0, false
+	}
+	chunk, posX, posZ := u.locate(x, z)
+	if chunk == nil {
+		return 0, false
+	}
+	return chunk.lightLevel(u.channel, posX, y, posZ), true
+}
+
+func (u *lightUpdate) isOpaque(x, y, z int) bool {
+	chunk, posX, posZ := u.locate(x, z)
+	if chunk == nil {
+		return true
+	}
+	block := chunk.At(posX, y, posZ)
+	return block == nil || block.IsSolid()
+}
+
+// emission returns the level the block at the world position emits on the
+// channel of the update.
+func (u *lightUpdate) emission(x, y, z int) uint8 {
+	if u.channel == skyChannel {
+		return 0
+	}
+	chunk, posX, posZ := u.locate(x, z)
+	if chunk == nil {
+		return 0
+	}
+	block := chunk.At(posX, y, posZ)
+	if block == nil {
+		return 0
+	}
+	return block.Emission().Block[u.channel-redChannel]
+}
+
+func (u *lightUpdate) set(x, y, z int, level uint8) {
+	chunk, posX, posZ := u.locate(x, z)
+	chunk.setLightLevel(u.channel, posX, y, posZ, level)
+	u.world.markLightDirty(x, y, z)
+}
+
+// propagate spreads the queued light, losing one level per block except when
+// full sky light travels straight down.
+func (u *lightUpdate) propagate() {
+	for head := 0; head < len(u.queue); head++ {
+		node := u.queue[head]
+		level, ok := u.level(node.x, node.y, node.z)
+		if !ok || level <= 1 {
+			continue
+		}
+
+		for direction, offset := range directionOffsets {
+			x, y, z := node.x+offset[0], node.y+offset[1], node.z+offset[2]
+			current, ok := u.level(x, y, z)
+			if !ok || u.isOpaque(x, y, z) {
+				continue
+			}
+
+			next := level - 1
+			if u.channel == skyChannel && Direction(direction) == Bottom && level == MAX_LIGHT {
+				next = MAX_LIGHT
+			}
+			if current < next {
+				u.set(x, y, z, next)
+				u.queue = append(u.queue, lightNode{x, y, z, next})
+			}
+		}
+	}
+	u.queue = u.queue[:0]
+}
+
+// unpropagate darkens everything lit by the queued removals. Light coming
+// from elsewhere, emitters included, is queued again so propagate can fill
+// the gap back in.
+func (u *lightUpdate) unpropagate() {
+	for head := 0; head < len(u.removal); head++ {
+		node := u.removal[head]
+
+		for direction, offset := range directionOffsets {
+			x, y, z := node.x+offset[0], node.y+offset[1], node.z+offset[2]
+			level, ok := u.level(x, y, z)
+			if !ok || level == 0 {
+				continue
+			}
+
+			fromSky := u.channel == skyChannel && Direction(direction) == Bottom && node.level == MAX_LIGHT
+			if level >= node.level && !fromSky {
+				u.queue = append(u.queue, lightNode{x, y, z, level})
+				continue
+			}
+
+			u.set(x, y, z, 0)
+			u.removal = append(u.removal, lightNode{x, y, z, level})
+			if emitted := u.emission(x, y, z); emitted > 0 {
+				u.set(x, y, z, emitted)
+				u.queue = append(u.queue, lightNode{x, y, z, emitted})
+			}
+		}
+	}
+	u.removal = u.removal[:0]
+}
+
+// LightChunk computes the sky and block light of a freshly generated chunk and
+// lets the light of its loaded neighbours flow in.
+func (w *World) LightChunk(chunk *Chunk) {
+	w.lightChunkSky(chunk)
+	w.lightChunkBlocks(chunk)
+}
+
+// markLightDirty schedules a rebuild of every section with a face lit by the
+// block at the world position. Snapshots are padded by a block on every side,
+// so at chunk edges, corners included, and section boundaries the block is
+// also read by the meshes of the neighbouring chunks and sections.
+func (w *World) markLightDirty(x, y, z int) {
+	for dx := -1; dx <= 1; dx++ {
+		for dz := -1; dz <= 1; dz++ {
+			chunk, _, _ := w.chunkAt(x+dx, z+dz)
+			if chunk == nil {
+				continue
+			}
+
+			chunk.MarkDirty(y)
+			if y%SECTION_SIZE == 0 {
+				chunk.MarkDirty(y - 1)
+			}
+			if y%SECTION_SIZE == SECTION_SIZE-1 {
+				chunk.MarkDirty(y + 1)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package world
+
+import (
+	minemath "github.com/wmattei/minceraft/math"
+)
+
+// MAX_DIRECTIONAL_LIGHTS is the number of directional lights the chunk shader
+// takes, the sun or moon included.
+const MAX_DIRECTIONAL_LIGHTS = 4
+
+// DirectionalLight lights every face turned towards Direction, which points
+// from the ground towards the light.
+type DirectionalLight struct {
+	Direction minemath.Vec3
+	Color     Color
+	Intensity float32
+}
+
+// LightingSettings configure how the sky lights the world. Everything here
+// is scaled by the sky light reaching a face and the sky brightness of the
+// time of day, block light is added on top by the shader.
+type LightingSettings struct {
+	// Ambient lights every face evenly.
+	Ambient          Color
+	AmbientIntensity float32
+
+	// The hemisphere light blends from the ground colour on faces looking
+	// down to the sky colour on faces looking up.
+	HemisphereSky       Color
+	HemisphereGround    Color
+	HemisphereIntensity float32
+
+	// The sun by day and the moon at night, following the world time. It's
+	// the only light casting shadows.
+	SunColor      Color
+	SunIntensity  float32
+	MoonColor     Color
+	MoonIntensity float32
+
+	// Lights are extra fill lights, only the first MAX_DIRECTIONAL_LIGHTS-1
+	// are used.
+	Lights []DirectionalLight
+
+	// FaceShading scales the light of every face by a fixed factor per
+	// direction, for the flat look of classic block games.
+	FaceShading        bool
+	FaceShadingFactors [6]float32
+}
+
+func DefaultLightingSettings() LightingSettings {
+	return LightingSettings{
+		Ambient:             WHITE,
+		AmbientIntensity:    0.15,
+		HemisphereSky:       Color{190, 215, 255},
+		HemisphereGround:    Color{120, 105, 90},
+		HemisphereIntensity: 0.3,
+		SunColor:            Color{255, 245, 225},
+		SunIntensity:        0.7,
+		MoonColor:           Color{170, 190, 255},
+		MoonIntensity:       0.5,
+		FaceShadingFactors: [6]float32{
+			Right:  0.6,
+			Left:   0.6,
+			Top:    1,
+			Bottom: 0.5,
+			Front:  0.8,
+			Back:   0.8,
+		},
+	}
+}
+
+func scaledColor(c Color, intensity float32) minemath.Vec3 {
+	v := c.ToVec4()
+	return minemath.Vec3{v[0] * intensity, v[1] * intensity, v[2] * intensity}
+}
+
+// directionalLights returns the sun or moon followed by the fill lights.
+func (s LightingSettings) directionalLights(time WorldTime) []DirectionalLight {
+	celestial := DirectionalLight{Direction: time.LightDirection(), Color: s.SunColor, Intensity: s.SunIntensity}
+	if time.SunDirection()[1] < 0 {
+		celestial.Color, celestial.Intensity = s.MoonColor, s.MoonIntensity
+	}
+
+	lights := append([]DirectionalLight{celestial}, s.Lights...)
+	return lights[:min(len(lights), MAX_DIRECTIONAL_LIGHTS)]
+}
+
+// Shade returns the sky light colour reaching a face, leaving out shadows. It
+// mirrors the chunk fragment shader.
+func (s LightingSettings) Shade(direction Direction, time WorldTime) minemath.Vec3 {
+	offset := directionOffsets[direction]
+	normal := minemath.Vec3{float32(offset[0]), float32(offset[1]), float32(offset[2])}
+
+	shade := scaledColor(s.Ambient, s.AmbientIntensity)
+	up := normal[1]*0.5 + 0.5
+	sky, ground := scaledColor(s.HemisphereSky, s.HemisphereIntensity), scaledColor(s.HemisphereGround, s.HemisphereIntensity)
+	shade = minemath.Add(shade, minemath.Add(ground.Mul(1-up), sky.Mul(up)))
+
+	for _, light := range s.directionalLights(time) {
+		diffuse := max(minemath.Dot(normal, minemath.Normalize(light.Direction)), 0)
+		shade = minemath.Add(shade, scaledColor(light.Color, light.Intensity*diffuse))
+	}
+
+	if s.FaceShading {
+		shade = shade.Mul(s.FaceShadingFactors[direction])
+	}
+	return shade.Mul(time.SkyBrightness())
+}
+
+// LightingUniforms are the values of the lighting uniforms of the chunk
+// program.
+type LightingUniforms struct {
+	Ambient          minemath.Vec3
+	HemisphereSky    minemath.Vec3
+	HemisphereGround minemath.Vec3
+	// LightCount of the directional lights are set, packed as vec3s.
+	LightCount      int
+	LightDirections [MAX_DIRECTIONAL_LIGHTS * 3]float32
+	LightColors     [MAX_DIRECTIONAL_LIGHTS * 3]float32
+	FaceShading     [6]float32
+	SkyBrightness   float32
+}
+
+// Uniforms lays the settings out for the chunk program.
+func (s LightingSettings) Uniforms(time WorldTime) LightingUniforms {
+	u := LightingUniforms{
+		Ambient:          scaledColor(s.Ambient, s.AmbientIntensity),
+		HemisphereSky:    scaledColor(s.HemisphereSky, s.HemisphereIntensity),
+		HemisphereGround: scaledColor(s.HemisphereGround, s.HemisphereIntensity),
+		FaceShading:      [6]float32{1, 1, 1, 1, 1, 1},
+		SkyBrightness:    time.SkyBrightness(),
+	}
+
+	lights := s.directionalLights(time)
+	for i, light := range lights {
+		direction := minemath.Normalize(light.Direction)
+		color := scaledColor(light.Color, light.Intensity)
+		copy(u.LightDirections[i*3:], direction[:])
+		copy(u.LightColors[i*3:], color[:])
+	}
+	u.LightCount = len(lights)
+
+	if s.FaceShading {
+		u.FaceShading = s.FaceShadingFactors
+	}
+	return u
+}
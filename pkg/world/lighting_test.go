@@ -0,0 +1,146 @@
+package world
+
+import "testing"
+
+func newLitTestWorld() *World {
+	world := newTestWorld(1)
+	for _, chunk := range world.chunks {
+		world.LightChunk(chunk)
+	}
+	return world
+}
+
+func TestSkyLightFollowsBlockChanges(t *testing.T) {
+	world := newLitTestWorld()
+
+	chunk := world.chunks[[2]int{0, 0}]
+	height := chunk.columnHeight(8, 8)
+	if light := chunk.SkyLight(8, height, 8); light != 0 {
+		t.Errorf("buried block light = %d, want 0", light)
+	}
+	if light := chunk.SkyLight(8, height+1, 8); light != MAX_LIGHT {
+		t.Fatalf("surface light = %d, want %d", light, MAX_LIGHT)
+	}
+
+	world.SetBlock(8, height+3, 8, Grass)
+	if light := chunk.SkyLight(8, height+1, 8); light >= MAX_LIGHT || light == 0 {
+		t.Errorf("light under an overhang = %d, want a level between 0 and %d", light, MAX_LIGHT)
+	}
+
+	world.SetBlock(8, height+3, 8, Air)
+	if light := chunk.SkyLight(8, height+1, 8); light != MAX_LIGHT {
+		t.Errorf("light after removing the overhang = %d, want %d", light, MAX_LIGHT)
+	}
+}
+
+func TestBlockLightCrossesChunkBorders(t *testing.T) {
+	world := newLitTestWorld()
+	chunk, neighbor := world.chunks[[2]int{0, 0}], world.chunks[[2]int{1, 0}]
+
+	// Bury an emitter in the ground on the border so only block light reaches
+	// the pocket dug next to it in the neighbouring chunk.
+	y := min(chunk.columnHeight(15, 8), neighbor.columnHeight(0, 8)) - 4
+	world.SetBlock(16, y, 8, Air)
+	world.SetBlock(15, y, 8, Glowstone)
+
+	emitted := chunk.At(15, y, 8).Emission()
+	light := neighbor.Light(0, y, 8)
+	if light.Sky != 0 {
+		t.Errorf("sky light of a buried pocket = %d, want 0", light.Sky)
+	}
+	for i, level := range light.Block {
+		if want := emitted.Block[i] - 1; level != want {
+			t.Errorf("channel %d next to the emitter = %d, want %d", i, level, want)
+		}
+	}
+	if light.Block[0] <= light.Block[2] {
+		t.Errorf("glowstone light %v should be warmer than it is blue", light.Block)
+	}
+
+	world.SetBlock(15, y, 8, Grass)
+	if light := neighbor.Light(0, y, 8); light.Block != [3]uint8{} {
+		t.Errorf("block light after removing the emitter = %v, want none", light.Block)
+	}
+}
+
+func TestSmoothLightingBlendsVertices(t *testing.T) {
+	s := sectionSnapshotPool.Get().(*SectionSnapshot)
+	defer s.Release()
+	for i := range s.blocks {
+		s.blocks[i] = blockSnapshot{}
+	}
+	s.at(5, 5, 5).Solid = true
+	s.at(5, 6, 5).Light.Sky = 12
+	s.at(6, 6, 5).Light.Sky = 12
+
+	s.SmoothLighting = false
+	if _, light := s.faceLighting(5, 5, 5, Top); light != [4]VoxelLight{{Sky: 12}, {Sky: 12}, {Sky: 12}, {Sky: 12}} {
+		t.Errorf("flat lighting = %v, want the light in front of the face", light)
+	}
+
+	s.SmoothLighting = true
+	_, light := s.faceLighting(5, 5, 5, Top)
+	for i, corner := range faceCorners[Top] {
+		want := uint8(3) // the front block averaged with three dark ones
+		if corner[0] == 1 {
+			want = 6 // one of the other three is lit as well
+		}
+		if light[i].Sky != want {
+			t.Errorf("vertex %v sky light = %d, want %d", corner, light[i].Sky, want)
+		}
+	}
+}
+
+func TestLODFacesTakeTheLightInFrontOfThem(t *testing.T) {
+	s := sectionSnapshotPool.Get().(*SectionSnapshot)
+	defer s.Release()
+	for i := range s.blocks {
+		s.blocks[i] = blockSnapshot{Solid: true}
+	}
+	// A pocket of one cell underground, out of the sky and lit by a red
+	// light.
+	for x := 4; x < 6; x++ {
+		for y := 4; y < 6; y++ {
+			for z := 4; z < 6; z++ {
+				*s.at(x, y, z) = blockSnapshot{Light: VoxelLight{Block: [3]uint8{12, 0, 0}}}
+			}
+		}
+	}
+	s.LOD = 1
+
+	var builder MeshBuilder
+	s.generateLODMeshData(&builder)
+	if len(builder.Vertices) == 0 {
+		t.Fatal("the walls of the pocket weren't meshed")
+	}
+	for i := 0; i < len(builder.Vertices); i += VERTEX_WORDS {
+		v := unpackVertex(builder.Vertices[i], builder.Vertices[i+1])
+		if v.Light != 0 || v.BlockLight != [3]int{12, 0, 0} {
+			t.Fatalf("a wall of the pocket has sky light %d and block light %v, want 0 and [12 0 0]", v.Light, v.BlockLight)
+		}
+	}
+}
+
+func TestLightAtACornerMarksTheDiagonalChunk(t *testing.T) {
+	world := newLitTestWorld()
+	for _, chunk := range world.chunks {
+		for _, section := range chunk.Sections {
+			section.NeedsUpdate = false
+		}
+	}
+
+	// The top corner of a section of chunk (0, 0), next to chunk (-1, -1).
+	y := 2*SECTION_SIZE - 1
+	world.markLightDirty(0, y, 0)
+	for _, pos := range [][2]int{{0, 0}, {-1, 0}, {0, -1}, {-1, -1}} {
+		for _, section := range world.chunks[pos].Sections {
+			want := section.Index == sectionIndex(y) || section.Index == sectionIndex(y+1)
+			if section.NeedsUpdate != want {
+				t.Errorf("chunk %v section %d: NeedsUpdate = %v, want %v", pos, section.Index, section.NeedsUpdate, want)
+			}
+		}
+	}
+	if world.chunks[[2]int{1, 1}].Sections[sectionIndex(y)].NeedsUpdate {
+		t.Error("a chunk away from the corner was marked")
+	}
+}
@@ -0,0 +1,216 @@
+package world
+
+import minemath "github.com/wmattei/minceraft/math"
+
+const MAX_LOD = 3
+
+// LOD_DISTANCES are the horizontal distances from the camera, in blocks, past
+// which chunks drop to the next level of detail. Level n meshes cells of 2^n
+// blocks per side.
+var LOD_DISTANCES = [MAX_LOD]float32{64, 112, 176}
+
+func lodForDistance(distance float32) int {
+	for lod, maxDistance := range LOD_DISTANCES {
+		if distance < maxDistance {
+			return lod
+		}
+	}
+	return MAX_LOD
+}
+
+// chunkLOD picks the level of detail of a chunk from the horizontal distance
+// between its center and the camera.
+func chunkLOD(chunk *Chunk, cameraPosition minemath.Vec3) int {
+	dx := float32(chunk.Position[0]*16+8) - cameraPosition[0]
+	dz := float32(chunk.Position[1]*16+8) - cameraPosition[2]
+
+	return lodForDistance(minemath.Vec3{dx, 0, dz}.Len())
+}
+
+// chooseLODs sets the level of detail of every chunk from its distance to
+// the center of the active chunk. Measuring from the chunk rather than from
+// the camera keeps the levels, and the meshes, unchanged until the camera
+// enters another chunk.
+func (w *World) chooseLODs() {
+	center := minemath.Vec3{float32(w.activeChunk[0]*16 + 8), 0, float32(w.activeChunk[1]*16 + 8)}
+	for _, chunk := range w.chunks {
+		if lod := chunkLOD(chunk, center); lod != chunk.LOD {
+			chunk.SetLOD(lod)
+		}
+	}
+	w.lodChosen = true
+}
+
+// lodCell is a downsampled cell of a section mesh.
+type lodCell struct {
+	solid  bool
+	layers [6]uint8
+}
+
+// generateLODMeshData meshes the section with cells of 2^LOD blocks per side.
+// A cell is solid when at least half of its blocks are, and takes the textures
+// of its highest block. Cells beyond the section are approximated from the
+// border layer of the snapshot. Surface cells always get their faces on the
+// chunk sides: those act as one cell deep skirts hiding the cracks between
+// chunks of different levels.
+func (s *SectionSnapshot) generateLODMeshData(builder *MeshBuilder) {
+	step := 1 << s.LOD
+	cells := SECTION_SIZE / step
+	minY := s.Section * SECTION_SIZE
+
+	if cap(builder.cells) < cells*cells*cells {
+		builder.cells = make([]lodCell, SECTION_SIZE*SECTION_SIZE*SECTION_SIZE)
+	}
+	grid := builder.cells[:cells*cells*cells]
+	cellAt := func(cx, cy, cz int) *lodCell {
+		return &grid[(cx*cells+cz)*cells+cy]
+	}
+
+	for cx := 0; cx < cells; cx++ {
+		for cz := 0; cz < cells; cz++ {
+			for cy := 0; cy < cells; cy++ {
+				*cellAt(cx, cy, cz) = s.downsample(cx*step, cy*step, cz*step, step)
+			}
+		}
+	}
+
+	isSurface := func(cx, cy, cz int) bool {
+		if cy+1 < cells {
+			return !cellAt(cx, cy+1, cz).solid
+		}
+		return !s.isLayerSolid(cx*step, SECTION_SIZE, cz*step, step)
+	}
+
+	noAO := [4]int{3, 3, 3, 3}
+
+	for cx := 0; cx < cells; cx++ {
+		for cz := 0; cz < cells; cz++ {
+			for cy := 0; cy < cells; cy++ {
+				cell := cellAt(cx, cy, cz)
+				if !cell.solid {
+					continue
+				}
+
+				for direction := Right; direction <= Back; direction++ {
+					offset := directionOffsets[direction]
+					nx, ny, nz := cx+offset[0], cy+offset[1], cz+offset[2]
+
+					var occluded bool
+					switch {
+					case nx < 0 || nx >= cells || nz < 0 || nz >= cells:
+						occluded = !isSurface(cx, cy, cz) && s.isSideLayerSolid(cx*step, cy*step, cz*step, step, direction)
+					case ny < 0:
+						occluded = s.isLayerSolid(cx*step, -1, cz*step, step)
+					case ny >= cells:
+						occluded = s.isLayerSolid(cx*step, SECTION_SIZE, cz*step, step)
+					default:
+						occluded = cellAt(nx, ny, nz).solid
+					}
+					if occluded {
+						continue
+					}
+
+					light := s.cellFaceLight(cx*step, cy*step, cz*step, step, direction)
+					builder.AddFace(cx*step, minY+cy*step, cz*step, step, direction, int(cell.layers[direction]), noAO, [4]VoxelLight{light, light, light, light}, false)
+				}
+			}
+		}
+	}
+}
+
+func (s *SectionSnapshot) downsample(x0, y0, z0, step int) lodCell {
+	var cell lodCell
+	solid := 0
+	topY := -1
+
+	for x := x0; x < x0+step; x++ {
+		for z := z0; z < z0+step; z++ {
+			for y := y0; y < y0+step; y++ {
+				block := s.at(x, y, z)
+				if !block.Solid {
+					continue
+				}
+				solid++
+				if y > topY {
+					topY = y
+					cell.layers = block.Layers
+				}
+			}
+		}
+	}
+
+	cell.solid = solid*2 >= step*step*step
+	return cell
+}
+
+// cellFaceLight returns the light of the face of a cell pointing in direction:
+// the brightest level of every channel in the layer of blocks just in front
+// of it, which the snapshot holds even beyond the section.
+func (s *SectionSnapshot) cellFaceLight(x0, y0, z0, step int, direction Direction) VoxelLight {
+	offset := directionOffsets[direction]
+	origin := [3]int{x0, y0, z0}
+	var from, to [3]int
+	for axis := range origin {
+		switch offset[axis] {
+		case 1:
+			from[axis], to[axis] = origin[axis]+step, origin[axis]+step
+		case -1:
+			from[axis], to[axis] = origin[axis]-1, origin[axis]-1
+		default:
+			from[axis], to[axis] = origin[axis], origin[axis]+step-1
+		}
+	}
+
+	var light VoxelLight
+	for x := from[0]; x <= to[0]; x++ {
+		for y := from[1]; y <= to[1]; y++ {
+			for z := from[2]; z <= to[2]; z++ {
+				block := s.at(x, y, z).Light
+				light.Sky = max(light.Sky, block.Sky)
+				for i := range light.Block {
+					light.Block[i] = max(light.Block[i], block.Block[i])
+				}
+			}
+		}
+	}
+	return light
+}
+
+// isLayerSolid approximates the cell beyond the top or bottom of the section
+// from the single layer of border blocks the snapshot holds.
+func (s *SectionSnapshot) isLayerSolid(x0, y, z0, step int) bool {
+	solid := 0
+	for x := x0; x < x0+step; x++ {
+		for z := z0; z < z0+step; z++ {
+			if s.isSolidAt(x, y, z) {
+				solid++
+			}
+		}
+	}
+	return solid*2 >= step*step
+}
+
+// isSideLayerSolid approximates the cell across a chunk side from the border
+// column of the snapshot touching that side of the cell.
+func (s *SectionSnapshot) isSideLayerSolid(x0, y0, z0, step int, direction Direction) bool {
+	solid := 0
+	for i := 0; i < step; i++ {
+		for y := y0; y < y0+step; y++ {
+			var x, z int
+			switch direction {
+			case Right:
+				x, z = 16, z0+i
+			case Left:
+				x, z = -1, z0+i
+			case Front:
+				x, z = x0+i, 16
+			case Back:
+				x, z = x0+i, -1
+			}
+			if s.isSolidAt(x, y, z) {
+				solid++
+			}
+		}
+	}
+	return solid*2 >= step*step
+}
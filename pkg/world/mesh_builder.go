@@ -0,0 +1,102 @@
+package world
+
+import "sync"
+
+// MESH_BUILDER_FACES is the number of faces a pooled mesh builder has room for
+// before its buffers need to grow. It covers a typical surface section.
+const MESH_BUILDER_FACES = 2048
+
+// MeshBuilder writes packed quads straight into buffers that are kept and
+// reused across rebuilds, so meshing a section doesn't allocate once the pool
+// is warm.
+type MeshBuilder struct {
+	Vertices []uint32
+	Indices  []uint32
+
+	// cells is scratch space for LOD meshing.
+	cells []lodCell
+	// visited and queue are scratch space for the visibility flood fill.
+	visited [SECTION_VOLUME]bool
+	queue   [][3]int
+}
+
+func (b *MeshBuilder) Reset() {
+	b.Vertices = b.Vertices[:0]
+	b.Indices = b.Indices[:0]
+}
+
+// AddFace appends the quad of a face of a size^3 cell whose minimum corner is
+// at (x, y, z). Cells larger than one block come from LOD meshes. The quad is
+// split along the diagonal whose vertices are the least occluded so the
+// shading gradient stays symmetric. Every vertex has its own light so it can
+// be interpolated across the face.
+func (b *MeshBuilder) AddFace(x, y, z, size int, direction Direction, layer int, ao [4]int, light [4]VoxelLight, highlighted bool) {
+	indexOffset := uint32(len(b.Vertices) / VERTEX_WORDS)
+
+	for i, corner := range faceCorners[direction] {
+		uv := faceUVs[direction][i]
+		data, attributes := packVertex(PackedVertex{
+			X:           x + corner[0]*size,
+			Y:           y + corner[1]*size,
+			Z:           z + corner[2]*size,
+			Normal:      direction,
+			U:           uv[0],
+			V:           uv[1],
+			Layer:       layer,
+			AO:          ao[i],
+			Light:       int(light[i].Sky),
+			BlockLight:  [3]int{int(light[i].Block[0]), int(light[i].Block[1]), int(light[i].Block[2])},
+			Highlighted: highlighted,
+		})
+		b.Vertices = append(b.Vertices, data, attributes)
+	}
+
+	if ao[0]+ao[2] < ao[1]+ao[3] {
+		b.Indices = append(b.Indices,
+			indexOffset+1, indexOffset+2, indexOffset+3,
+			indexOffset+1, indexOffset+3, indexOffset,
+		)
+		return
+	}
+
+	b.Indices = append(b.Indices,
+		indexOffset, indexOffset+1, indexOffset+2,
+		indexOffset, indexOffset+2, indexOffset+3,
+	)
+}
+
+var sectionMeshPool = sync.Pool{
+	New: func() any {
+		return &SectionMesh{
+			MeshBuilder: MeshBuilder{
+				Vertices: make([]uint32, 0, MESH_BUILDER_FACES*4*VERTEX_WORDS),
+				Indices:  make([]uint32, 0, MESH_BUILDER_FACES*6),
+			},
+		}
+	},
+}
+
+// SectionMesh is a finished section mesh. Its buffers belong to a pool and
+// must be released once they've been uploaded.
+type SectionMesh struct {
+	Position [2]int
+	Section  int
+	Version  uint64
+	// Visibility is computed from the same snapshot as the mesh.
+	Visibility SectionVisibility
+
+	MeshBuilder
+}
+
+func newSectionMesh(position [2]int, section int, version uint64) *SectionMesh {
+	mesh := sectionMeshPool.Get().(*SectionMesh)
+	mesh.Position = position
+	mesh.Section = section
+	mesh.Version = version
+	mesh.Reset()
+	return mesh
+}
+
+func (m *SectionMesh) Release() {
+	sectionMeshPool.Put(m)
+}
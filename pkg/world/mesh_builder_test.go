@@ -0,0 +1,77 @@
+package world
+
+import (
+	"slices"
+	"testing"
+)
+
+// surfaceSection holds most of the terrain surface.
+var surfaceSection = sectionIndex(SEA_LEVEL)
+
+func BenchmarkBuildSectionMesh(b *testing.B) {
+	chunk := newTestWorld(1).chunks[[2]int{0, 0}]
+	snapshot := chunk.Snapshot(surfaceSection)
+	snapshot.BuildMesh().Release()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		snapshot.BuildMesh().Release()
+	}
+}
+
+func BenchmarkRebuildSection(b *testing.B) {
+	chunk := newTestWorld(1).chunks[[2]int{0, 0}]
+	section := surfaceSection
+	snapshot := chunk.Snapshot(section)
+	snapshot.BuildMesh().Release()
+	snapshot.Release()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		snapshot := chunk.Snapshot(section)
+		snapshot.BuildMesh().Release()
+		snapshot.Release()
+	}
+}
+
+func BenchmarkRebuildSectionLOD(b *testing.B) {
+	chunk := newTestWorld(1).chunks[[2]int{0, 0}]
+	chunk.LOD = 1
+	section := surfaceSection
+	snapshot := chunk.Snapshot(section)
+	snapshot.BuildMesh().Release()
+	snapshot.Release()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		snapshot := chunk.Snapshot(section)
+		snapshot.BuildMesh().Release()
+		snapshot.Release()
+	}
+}
+
+func TestMeshBuilderReuseMatchesFreshBuild(t *testing.T) {
+	chunk := newTestWorld(1).chunks[[2]int{0, 0}]
+	snapshot := chunk.Snapshot(surfaceSection)
+	defer snapshot.Release()
+
+	first := snapshot.BuildMesh()
+	vertices := append([]uint32(nil), first.Vertices...)
+	indices := append([]uint32(nil), first.Indices...)
+	first.Release()
+
+	second := snapshot.BuildMesh()
+	defer second.Release()
+	if len(vertices) == 0 {
+		t.Fatal("expected a non-empty mesh")
+	}
+	if !slices.Equal(second.Vertices, vertices) || !slices.Equal(second.Indices, indices) {
+		t.Error("mesh built into a reused builder differs from the first build")
+	}
+}
@@ -0,0 +1,98 @@
+package world
+
+import (
+	"sync"
+	"time"
+)
+
+// MESH_UPLOAD_BUDGET is how long the render loop may spend uploading finished
+// meshes to the GPU each frame.
+const MESH_UPLOAD_BUDGET = 4 * time.Millisecond
+
+// MeshWorkerPool builds section meshes from snapshots on background goroutines.
+// Finished meshes are queued until the GL thread drains them.
+type MeshWorkerPool struct {
+	jobs chan *SectionSnapshot
+	wg   sync.WaitGroup
+
+	mu      sync.Mutex
+	results []*SectionMesh
+}
+
+func NewMeshWorkerPool(workers, queueSize int) *MeshWorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	pool := &MeshWorkerPool{
+		jobs: make(chan *SectionSnapshot, queueSize),
+	}
+
+	for i := 0; i < workers; i++ {
+		pool.wg.Add(1)
+		go pool.work()
+	}
+
+	return pool
+}
+
+func (p *MeshWorkerPool) work() {
+	defer p.wg.Done()
+
+	for snapshot := range p.jobs {
+		mesh := snapshot.BuildMesh()
+		snapshot.Release()
+
+		p.mu.Lock()
+		p.results = append(p.results, mesh)
+		p.mu.Unlock()
+	}
+}
+
+// HasCapacity reports whether Submit would accept another job. Only the
+// submitting goroutine may rely on the answer.
+func (p *MeshWorkerPool) HasCapacity() bool {
+	return len(p.jobs) < cap(p.jobs)
+}
+
+// Submit queues a snapshot for meshing without blocking. It returns false when
+// the queue is full, in which case the caller should retry on a later frame.
+func (p *MeshWorkerPool) Submit(snapshot *SectionSnapshot) bool {
+	select {
+	case p.jobs <- snapshot:
+		return true
+	default:
+		return false
+	}
+}
+
+// Drain hands finished meshes to upload until the budget is spent. At least one
+// mesh is uploaded per call so the queue always makes progress. upload owns the
+// meshes it receives and must release them.
+func (p *MeshWorkerPool) Drain(budget time.Duration, upload func(*SectionMesh)) {
+	startedAt := time.Now()
+
+	for {
+		p.mu.Lock()
+		if len(p.results) == 0 {
+			p.mu.Unlock()
+			return
+		}
+		mesh := p.results[0]
+		p.results[0] = nil
+		p.results = p.results[1:]
+		p.mu.Unlock()
+
+		upload(mesh)
+
+		if time.Since(startedAt) >= budget {
+			return
+		}
+	}
+}
+
+// Stop waits for the in-flight jobs to finish and shuts the workers down.
+func (p *MeshWorkerPool) Stop() {
+	close(p.jobs)
+	p.wg.Wait()
+}
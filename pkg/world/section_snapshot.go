@@ -0,0 +1,178 @@
+package world
+
+import "sync"
+
+// SNAPSHOT_SIZE is the size of a snapshot along each axis: the section plus a
+// one block border copied from the neighbouring sections and chunks.
+const SNAPSHOT_SIZE = SECTION_SIZE + 2
+
+type blockSnapshot struct {
+	Solid       bool
+	Highlighted bool
+	Light       VoxelLight
+	Layers      [6]uint8
+}
+
+// SectionSnapshot is an immutable copy of everything the mesher needs to know
+// about a chunk section, so meshes can be built away from the render loop while
+// the world keeps changing.
+type SectionSnapshot struct {
+	Position [2]int
+	Section  int
+	Version  uint64
+	LOD      int
+	// SmoothLighting interpolates the light across faces, see faceLighting.
+	SmoothLighting bool
+
+	// skirts is a bitmask of the chunk sides, indexed by Direction, whose
+	// border faces are always emitted to hide cracks against a neighbour
+	// meshed at another level of detail.
+	skirts uint8
+	blocks []blockSnapshot
+}
+
+var sectionSnapshotPool = sync.Pool{
+	New: func() any {
+		return &SectionSnapshot{
+			blocks: make([]blockSnapshot, SNAPSHOT_SIZE*SNAPSHOT_SIZE*SNAPSHOT_SIZE),
+		}
+	},
+}
+
+// Snapshot copies a section and the border blocks around it. It must be called
+// from the goroutine that owns the world, and the snapshot released once the
+// mesh is built.
+func (c *Chunk) Snapshot(section int) *SectionSnapshot {
+	s := sectionSnapshotPool.Get().(*SectionSnapshot)
+	s.Position = c.Position
+	s.Section = section
+	s.Version = c.Sections[section].meshVersion
+	s.LOD = c.LOD
+	s.SmoothLighting = c.World.smoothLighting
+	s.skirts = 0
+	minY := section * SECTION_SIZE
+
+	for i, neighbor := range c.GetAllNeighbors() {
+		if neighbor != nil && neighbor.LOD != c.LOD {
+			s.skirts |= 1 << chunkSides[i]
+		}
+	}
+
+	for x := -1; x <= 16; x++ {
+		for z := -1; z <= 16; z++ {
+			for y := -1; y <= SECTION_SIZE; y++ {
+				inside := x >= 0 && x < 16 && z >= 0 && z < 16 && y >= 0 && y < SECTION_SIZE
+				owner, posX, posZ := c, x, z
+				if x < 0 || x >= 16 || z < 0 || z >= 16 {
+					owner, posX, posZ = c.World.chunkAt(c.Position[0]*16+x, c.Position[1]*16+z)
+				}
+
+				snapshot := &s.blocks[snapshotIndex(x, y, z)]
+				*snapshot = blockSnapshot{Light: fullSkyLight}
+				if minY+y < 0 {
+					// Nothing can see the bottom of the world.
+					snapshot.Solid = true
+					continue
+				}
+				if owner == nil {
+					continue
+				}
+
+				snapshot.Light = owner.Light(posX, minY+y, posZ)
+				block := owner.At(posX, minY+y, posZ)
+				if block == nil || !block.IsSolid() {
+					continue
+				}
+
+				snapshot.Solid = true
+				if !inside {
+					continue
+				}
+
+				snapshot.Highlighted = block.Highlighted
+				for direction, face := range block.Faces {
+					snapshot.Layers[direction] = uint8(face.Texture.Index)
+				}
+			}
+		}
+	}
+
+	return s
+}
+
+func (s *SectionSnapshot) Release() {
+	sectionSnapshotPool.Put(s)
+}
+
+// snapshotIndex maps section-local coordinates, including the border at -1
+// and 16, to an index into the snapshot.
+func snapshotIndex(x, y, z int) int {
+	return ((x+1)*SNAPSHOT_SIZE+(z+1))*SNAPSHOT_SIZE + y + 1
+}
+
+func (s *SectionSnapshot) at(x, y, z int) *blockSnapshot {
+	if x < -1 || x > 16 || y < -1 || y > SECTION_SIZE || z < -1 || z > 16 {
+		return nil
+	}
+	return &s.blocks[snapshotIndex(x, y, z)]
+}
+
+func (s *SectionSnapshot) isSolidAt(x, y, z int) bool {
+	block := s.at(x, y, z)
+	return block != nil && block.Solid
+}
+
+// BuildMesh meshes the snapshot into a pooled mesh.
+func (s *SectionSnapshot) BuildMesh() *SectionMesh {
+	mesh := newSectionMesh(s.Position, s.Section, s.Version)
+	mesh.Visibility = s.visibility(&mesh.MeshBuilder)
+	if s.LOD > 0 {
+		s.generateLODMeshData(&mesh.MeshBuilder)
+	} else {
+		s.generateMeshData(&mesh.MeshBuilder)
+	}
+	return mesh
+}
+
+func (s *SectionSnapshot) generateMeshData(builder *MeshBuilder) {
+	minY := s.Section * SECTION_SIZE
+
+	for x := 0; x < 16; x++ {
+		for y := 0; y < SECTION_SIZE; y++ {
+			for z := 0; z < 16; z++ {
+				block := s.at(x, y, z)
+				if !block.Solid {
+					continue
+				}
+				for direction := Right; direction <= Back; direction++ {
+					offset := directionOffsets[direction]
+					front := s.at(x+offset[0], y+offset[1], z+offset[2])
+					if front.Solid && !s.isSkirtFace(x, z, direction) {
+						continue
+					}
+					ao, light := s.faceLighting(x, y, z, direction)
+					builder.AddFace(x, minY+y, z, 1, direction, int(block.Layers[direction]), ao, light, block.Highlighted)
+				}
+			}
+		}
+	}
+}
+
+// isSkirtFace reports whether the face lies on a chunk side that needs a skirt.
+func (s *SectionSnapshot) isSkirtFace(x, z int, direction Direction) bool {
+	if s.skirts&(1<<direction) == 0 {
+		return false
+	}
+
+	switch direction {
+	case Right:
+		return x == 15
+	case Left:
+		return x == 0
+	case Front:
+		return z == 15
+	case Back:
+		return z == 0
+	}
+	return false
+}
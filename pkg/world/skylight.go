@@ -0,0 +1,122 @@
+package world
+
+// SkyLight returns the sky light level at the chunk-local position. Positions
+// above the world are fully lit, the ones below it are dark.
+func (c *Chunk) SkyLight(x, y, z int) uint8 {
+	if y >= WORLD_HEIGHT {
+		return MAX_LIGHT
+	}
+	if y < 0 {
+		return 0
+	}
+	return c.Sections[sectionIndex(y)].SkyLight[sectionLightIndex(x, y, z)]
+}
+
+func (c *Chunk) setSkyLight(x, y, z int, level uint8) {
+	c.Sections[sectionIndex(y)].SkyLight[sectionLightIndex(x, y, z)] = level
+}
+
+// columnHeight returns the y of the highest opaque block of the column, or -1
+// when the column is open down to the bottom of the world.
+func (c *Chunk) columnHeight(x, z int) int {
+	for y := WORLD_HEIGHT - 1; y >= 0; y-- {
+		if c.At(x, y, z).IsSolid() {
+			return y
+		}
+	}
+	return -1
+}
+
+// lightChunkSky lights every column of the chunk down to its first opaque
+// block, then flood fills the light sideways into overhangs and caves and
+// across the borders shared with the loaded neighbours.
+func (w *World) lightChunkSky(chunk *Chunk) {
+	var heights [16][16]int
+	for x := 0; x < 16; x++ {
+		for z := 0; z < 16; z++ {
+			height := chunk.columnHeight(x, z)
+			heights[x][z] = height
+			for y := WORLD_HEIGHT - 1; y >= 0; y-- {
+				level := uint8(0)
+				if y > height {
+					level = MAX_LIGHT
+				}
+				chunk.setSkyLight(x, y, z, level)
+			}
+		}
+	}
+
+	u := newLightUpdate(w, skyChannel)
+	originX, originZ := chunk.Position[0]*16, chunk.Position[1]*16
+
+	neighborHeight := func(x, z int) int {
+		if x >= 0 && x < 16 && z >= 0 && z < 16 {
+			return heights[x][z]
+		}
+		neighbor, posX, posZ := w.chunkAt(originX+x, originZ+z)
+		if neighbor == nil {
+			return -1
+		}
+		return neighbor.columnHeight(posX, posZ)
+	}
+
+	// Only sky light next to a taller column can reach anything darker.
+	for x := 0; x < 16; x++ {
+		for z := 0; z < 16; z++ {
+			top := -1
+			for _, side := range chunkSides {
+				offset := directionOffsets[side]
+				top = max(top, neighborHeight(x+offset[0], z+offset[2]))
+			}
+			for y := heights[x][z] + 1; y <= top; y++ {
+				u.queue = append(u.queue, lightNode{originX + x, y, originZ + z, MAX_LIGHT})
+			}
+		}
+	}
+
+	// Light already in the neighbours flows in through the shared borders.
+	for i := 0; i < 16; i++ {
+		for _, border := range [4][2]int{{-1, i}, {16, i}, {i, -1}, {i, 16}} {
+			x, z := originX+border[0], originZ+border[1]
+			top := heights[min(max(border[0], 0), 15)][min(max(border[1], 0), 15)]
+			for y := 0; y <= top; y++ {
+				if level, ok := u.level(x, y, z); ok && level > 1 {
+					u.queue = append(u.queue, lightNode{x, y, z, level})
+				}
+			}
+		}
+	}
+
+	u.propagate()
+}
+
+// updateSkyLight fixes the sky light after the block at the world position
+// changed, darkening what it now shadows or relighting what it uncovered.
+func (w *World) updateSkyLight(x, y, z int) {
+	u := newLightUpdate(w, skyChannel)
+
+	if u.isOpaque(x, y, z) {
+		level, ok := u.level(x, y, z)
+		if !ok {
+			return
+		}
+		u.set(x, y, z, 0)
+		u.removal = append(u.removal, lightNode{x, y, z, level})
+		u.unpropagate()
+	} else {
+		for _, offset := range directionOffsets {
+			nx, ny, nz := x+offset[0], y+offset[1], z+offset[2]
+			if ny >= WORLD_HEIGHT {
+				// The top of the world is open sky.
+				u.set(x, y, z, MAX_LIGHT)
+				u.queue = append(u.queue, lightNode{x, y, z, MAX_LIGHT})
+				continue
+			}
+			if level, ok := u.level(nx, ny, nz); ok && level > 0 {
+				u.queue = append(u.queue, lightNode{nx, ny, nz, level})
+			}
+		}
+	}
+
+	u.propagate()
+}
@@ -0,0 +1,92 @@
+package world
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/wmattei/minceraft/pkg/atlas"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+// Animation describes a texture whose image is a vertical strip of square
+// frames, the first one at the top.
+type Animation struct {
+	// FrameTime is how long a frame is shown, in seconds.
+	FrameTime float64 `json:"frameTime"`
+	// Frames is the order the frames are shown in, every frame of the strip
+	// from the top when empty.
+	Frames []int `json:"frames"`
+	// Interpolate fades each frame into the next one instead of switching.
+	Interpolate bool `json:"interpolate"`
+}
+
+// TextureAnimation plays an animation on a sprite of the block textures. Only
+// the sprite table changes, meshes keep pointing at the same sprite.
+type TextureAnimation struct {
+	Index     int
+	Animation Animation
+
+	base     render.Sprite
+	frameUVs [][4]float32
+	current  render.Sprite
+}
+
+func newTextureAnimation(texture Texture, sprite render.Sprite, placed atlas.Sprite) (*TextureAnimation, error) {
+	animation := *texture.Animation
+	if animation.FrameTime <= 0 {
+		return nil, fmt.Errorf("%s: the frame time must be positive", texture.Path)
+	}
+	if placed.Width == 0 || placed.Height%placed.Width != 0 {
+		return nil, fmt.Errorf("%s: %dx%d isn't a strip of square frames", texture.Path, placed.Width, placed.Height)
+	}
+
+	count := placed.Height / placed.Width
+	if len(animation.Frames) == 0 {
+		for i := 0; i < count; i++ {
+			animation.Frames = append(animation.Frames, i)
+		}
+	}
+
+	frameHeight := (sprite.UV[3] - sprite.UV[1]) / float32(count)
+	a := &TextureAnimation{Index: texture.Index, Animation: animation, base: sprite}
+	for _, frame := range animation.Frames {
+		if frame < 0 || frame >= count {
+			return nil, fmt.Errorf("%s: frame %d isn't one of the %d frames of the strip", texture.Path, frame, count)
+		}
+		top := sprite.UV[1] + float32(frame)*frameHeight
+		a.frameUVs = append(a.frameUVs, [4]float32{sprite.UV[0], top, sprite.UV[2], top + frameHeight})
+	}
+	a.current = a.Sprite(0)
+
+	return a, nil
+}
+
+// Sprite is the sprite to draw seconds into the animation.
+func (a *TextureAnimation) Sprite(seconds float64) render.Sprite {
+	step, blend := math.Modf(seconds / a.Animation.FrameTime)
+	frame := int(step) % len(a.frameUVs)
+
+	sprite := a.base
+	sprite.UV = a.frameUVs[frame]
+	if a.Animation.Interpolate {
+		sprite.NextUV = a.frameUVs[(frame+1)%len(a.frameUVs)]
+		sprite.Blend = float32(blend)
+	}
+	return sprite
+}
+
+// AnimateTextures moves the texture animations forward by dt seconds.
+func (w *World) AnimateTextures(dt float64) {
+	if w.blockTextures == nil {
+		return
+	}
+
+	w.animationTime += dt
+	for _, animation := range w.animations {
+		sprite := animation.Sprite(w.animationTime)
+		if sprite != animation.current {
+			w.blockTextures.SetSprite(animation.Index, sprite)
+			animation.current = sprite
+		}
+	}
+}
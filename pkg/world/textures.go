@@ -0,0 +1,255 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	minemath "github.com/wmattei/minceraft/math"
+	"github.com/wmattei/minceraft/pkg/atlas"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+type TextureSide string
+
+const (
+	TopText    TextureSide = "top"
+	BottomText TextureSide = "bottom"
+	SideText   TextureSide = "side"
+)
+
+type Texture struct {
+	ColorStr string `json:"color"`
+	Color    *Color `json:"-"`
+	Path     string `json:"path"`
+	// Index is the sprite of the texture in the block texture array.
+	Index     int        `json:"-"`
+	Animation *Animation `json:"animation"`
+	// Removed is set on textures kept after they left the manifest, they are
+	// drawn with the missing texture.
+	Removed bool `json:"-"`
+}
+
+type TextureFile map[BlockType]map[TextureSide]Texture
+
+// TEXTURE_DIR is the directory stitched into the atlas at startup. Sprites
+// are named after the path of their image relative to it.
+const TEXTURE_DIR = "assets/textures"
+
+// MISSING_SPRITE is the sprite of the base pack drawn in place of textures
+// that are no longer available.
+const MISSING_SPRITE = "missing"
+
+// LoadAtlas reads the atlas written by cmd/atlas at manifestPath or, without
+// one, stitches TEXTURE_DIR of the assets.
+func LoadAtlas(assets fs.FS, manifestPath string) *atlas.Atlas {
+	a, err := loadAtlas(assets, manifestPath)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func loadAtlas(assets fs.FS, manifestPath string) (*atlas.Atlas, error) {
+	if manifestPath != "" {
+		return atlas.Load(os.DirFS(filepath.Dir(manifestPath)), filepath.Base(manifestPath))
+	}
+	textures, err := fs.Sub(assets, TEXTURE_DIR)
+	if err != nil {
+		return nil, err
+	}
+	return atlas.Build(textures, atlas.DefaultOptions())
+}
+
+// LoadTextures uploads the atlas pages, with one sprite per texture, and
+// returns the animations to play on them.
+func LoadTextures(uploader render.TextureUploader, textures map[string]Texture, a *atlas.Atlas) (render.SpriteTable, []*TextureAnimation) {
+	table, animations, err := uploadTextures(uploader, textures, a)
+	if err != nil {
+		panic(err)
+	}
+	return table, animations
+}
+
+func uploadTextures(uploader render.TextureUploader, textures map[string]Texture, a *atlas.Atlas) (render.SpriteTable, []*TextureAnimation, error) {
+	sprites, animations, err := textureSprites(textures, a)
+	if err != nil {
+		return nil, nil, err
+	}
+	table, err := uploader.UploadTextures(a.Pages, a.MipLevels, sprites)
+	if err != nil {
+		return nil, nil, err
+	}
+	return table, animations, nil
+}
+
+// textureSprites finds the textures in the atlas, ordered by their index.
+// Animated textures start at their first frame. Removed textures get the
+// missing sprite, their image may have gone with a resource pack.
+func textureSprites(textures map[string]Texture, a *atlas.Atlas) ([]render.Sprite, []*TextureAnimation, error) {
+	sprites := make([]render.Sprite, len(textures))
+	var animations []*TextureAnimation
+	for name, texture := range textures {
+		if texture.Removed {
+			placed, ok := a.Sprites[MISSING_SPRITE]
+			if !ok {
+				return nil, nil, fmt.Errorf("texture %s was removed and the atlas has no %s sprite", name, MISSING_SPRITE)
+			}
+			sprites[texture.Index] = render.Sprite{Page: placed.Page, UV: placed.UV, Tint: minemath.Vec3{1, 1, 1}}
+			continue
+		}
+
+		placed, ok := a.Sprites[spriteName(texture.Path)]
+		if !ok {
+			return nil, nil, fmt.Errorf("texture %s: %s is not in the atlas", name, texture.Path)
+		}
+
+		tint := WHITE.ToVec4()
+		if texture.Color != nil {
+			tint = texture.Color.ToVec4()
+		}
+		sprite := render.Sprite{
+			Page: placed.Page,
+			UV:   placed.UV,
+			Tint: minemath.Vec3{tint[0], tint[1], tint[2]},
+		}
+
+		if texture.Animation != nil {
+			animation, err := newTextureAnimation(texture, sprite, placed)
+			if err != nil {
+				return nil, nil, err
+			}
+			animations = append(animations, animation)
+			sprite = animation.current
+		}
+		sprites[texture.Index] = sprite
+	}
+	return sprites, animations, nil
+}
+
+// spriteName maps a manifest path such as
+// "assets/textures/block/grass_block_top.png" to "block/grass_block_top".
+func spriteName(texturePath string) string {
+	name := filepath.ToSlash(texturePath)
+	name = strings.TrimPrefix(name, TEXTURE_DIR+"/")
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// TEXTURE_MANIFEST lists the textures of every block side.
+const TEXTURE_MANIFEST = TEXTURE_DIR + "/texture_atlas.json"
+
+// LoadTextureManifest reads the texture manifest without touching the GPU, so it
+// can be used by headless tools. Textures are numbered in the order of their
+// names, so reading the same manifest again gives them the same indices.
+func LoadTextureManifest(assets fs.FS) map[string]Texture {
+	textures, err := readTextureManifest(assets)
+	if err != nil {
+		panic(err)
+	}
+	return textures
+}
+
+func readTextureManifest(assets fs.FS) (map[string]Texture, error) {
+	texturesFile, err := assets.Open(TEXTURE_MANIFEST)
+	if err != nil {
+		return nil, err
+	}
+	defer texturesFile.Close()
+
+	var file TextureFile
+
+	if err := json.NewDecoder(texturesFile).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", TEXTURE_MANIFEST, err)
+	}
+
+	var result = make(map[string]Texture)
+
+	var names []string
+	for blockType, textures := range file {
+		for side := range textures {
+			names = append(names, string(blockType)+string(side))
+		}
+	}
+	sort.Strings(names)
+	indices := make(map[string]int, len(names))
+	for i, name := range names {
+		indices[name] = i
+	}
+
+	for blockType, textures := range file {
+		for side, texture := range textures {
+			texName := string(blockType) + string(side)
+			if texture.ColorStr != "" {
+				color, ok := parseColor(texture.ColorStr)
+				if !ok {
+					return nil, fmt.Errorf("%s: texture %s: bad color %q", TEXTURE_MANIFEST, texName, texture.ColorStr)
+				}
+				texture.Color = color
+			}
+			texture.Index = indices[texName]
+
+			result[texName] = texture
+		}
+	}
+
+	if len(result) > render.MAX_SPRITES {
+		return nil, fmt.Errorf("the texture manifest has %d textures, at most %d are supported", len(result), render.MAX_SPRITES)
+	}
+
+	return result, nil
+}
+
+// parseColor reads a colour written as "r,g,b", with components from 0 to 255.
+func parseColor(s string) (*Color, bool) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	var components [3]int
+	for i, part := range parts {
+		c, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || c < 0 || c > 255 {
+			return nil, false
+		}
+		components[i] = c
+	}
+	return &Color{R: components[0], G: components[1], B: components[2]}, true
+}
+
+// keepTextureIndices numbers reloaded textures: those already loaded keep
+// their index and new ones are numbered after them.
+// Textures that left the manifest are kept, blocks may still use them, and
+// marked as removed.
+func keepTextureIndices(loaded, reloaded map[string]Texture) map[string]Texture {
+	result := make(map[string]Texture, len(reloaded))
+	var added []string
+	for name, texture := range reloaded {
+		if old, ok := loaded[name]; ok {
+			texture.Index = old.Index
+			result[name] = texture
+		} else {
+			added = append(added, name)
+		}
+	}
+
+	sort.Strings(added)
+	for i, name := range added {
+		texture := reloaded[name]
+		texture.Index = len(loaded) + i
+		result[name] = texture
+	}
+
+	for name, texture := range loaded {
+		if _, ok := result[name]; !ok {
+			texture.Removed = true
+			result[name] = texture
+		}
+	}
+	return result
+}
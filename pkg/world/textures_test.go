@@ -0,0 +1,146 @@
+package world
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/wmattei/minceraft/pkg/atlas"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+// baseAssets is the built-in resource pack, at the root of the repository.
+const baseAssets = "../.."
+
+func TestTextureSpritesFollowTheManifest(t *testing.T) {
+	textures := LoadTextureManifest(os.DirFS(baseAssets))
+	a := &atlas.Atlas{Sprites: make(map[string]atlas.Sprite)}
+	for _, texture := range textures {
+		a.Sprites[spriteName(texture.Path)] = atlas.Sprite{Page: 1, Width: 16, Height: 64, UV: [4]float32{0.25, 0.5, 0.75, 1}}
+	}
+
+	sprites, animations, err := textureSprites(textures, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sprites) != len(textures) {
+		t.Fatalf("got %d sprites for %d textures", len(sprites), len(textures))
+	}
+	for name, texture := range textures {
+		if sprite := sprites[texture.Index]; sprite.Page != 1 || sprite.UV[0] != 0.25 || sprite.UV[2] != 0.75 {
+			t.Errorf("sprite of %s is %+v", name, sprite)
+		}
+	}
+
+	if len(animations) != 4 {
+		t.Errorf("got %d animations, want the water and lava ones", len(animations))
+	}
+	if top := sprites[textures["watertop"].Index].UV[3]; top != 0.625 {
+		t.Errorf("water starts with a frame ending at %v, want the first of four", top)
+	}
+
+	water := sprites[textures["watertop"].Index].Tint
+	if want := textures["watertop"].Color.ToVec4(); water[0] != want[0] || water[1] != want[1] || water[2] != want[2] {
+		t.Errorf("water is tinted %v, want %v", water, want)
+	}
+	if grass := sprites[textures["grassbottom"].Index].Tint; grass[0] != 1 || grass[1] != 1 || grass[2] != 1 {
+		t.Errorf("untinted grass is tinted %v", grass)
+	}
+
+	delete(a.Sprites, "block/water_still")
+	if _, _, err := textureSprites(textures, a); err == nil {
+		t.Error("a texture missing from the atlas was accepted")
+	}
+}
+
+func TestTextureAnimationPlaysFrames(t *testing.T) {
+	texture := Texture{Path: "lava.png", Animation: &Animation{FrameTime: 0.5, Frames: []int{2, 0}}}
+	sprite := render.Sprite{Page: 3, UV: [4]float32{0, 0, 0.5, 1}}
+	placed := atlas.Sprite{Width: 16, Height: 64}
+
+	animation, err := newTextureAnimation(texture, sprite, placed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, step := range []struct {
+		seconds float64
+		top     float32
+	}{{0, 0.5}, {0.6, 0}, {1.1, 0.5}} {
+		if got := animation.Sprite(step.seconds); got.UV[1] != step.top || got.Page != 3 || got.Blend != 0 {
+			t.Errorf("at %vs the sprite is %+v, want the frame at %v", step.seconds, got, step.top)
+		}
+	}
+
+	animation.Animation.Interpolate = true
+	if got := animation.Sprite(0.25); got.NextUV[1] != 0 || got.Blend != 0.5 {
+		t.Errorf("halfway through the first frame the sprite is %+v", got)
+	}
+
+	texture.Animation.Frames = []int{4}
+	if _, err := newTextureAnimation(texture, sprite, placed); err == nil {
+		t.Error("a frame past the end of the strip was accepted")
+	}
+}
+
+func TestReloadedTexturesKeepTheirIndex(t *testing.T) {
+	loaded := map[string]Texture{
+		"grasstop":  {Path: "grass_top.png", Index: 0},
+		"grassside": {Path: "grass_side.png", Index: 1},
+		"lavatop":   {Path: "lava.png", Index: 2},
+	}
+	reloaded := map[string]Texture{
+		"stonetop":  {Path: "stone.png", Index: 0},
+		"grassside": {Path: "pack/grass_side.png", Index: 1},
+		"grasstop":  {Path: "pack/grass_top.png", Index: 2},
+		"dirttop":   {Path: "dirt.png", Index: 3},
+	}
+
+	textures := keepTextureIndices(loaded, reloaded)
+
+	for name, want := range map[string]int{"grasstop": 0, "grassside": 1, "lavatop": 2, "dirttop": 3, "stonetop": 4} {
+		if got := textures[name].Index; got != want {
+			t.Errorf("%s has index %d, want %d", name, got, want)
+		}
+	}
+	if textures["grasstop"].Path != "pack/grass_top.png" {
+		t.Errorf("the reloaded grass top reads %s", textures["grasstop"].Path)
+	}
+	if !textures["lavatop"].Removed || textures["grasstop"].Removed {
+		t.Error("only the texture that left the manifest should be removed")
+	}
+
+	a := &atlas.Atlas{Sprites: map[string]atlas.Sprite{
+		MISSING_SPRITE:    {Page: 2},
+		"pack/grass_top":  {},
+		"pack/grass_side": {},
+		"dirt":            {},
+		"stone":           {},
+	}}
+	sprites, _, err := textureSprites(textures, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sprites[textures["lavatop"].Index].Page != 2 {
+		t.Error("the removed texture isn't drawn with the missing sprite")
+	}
+}
+
+func TestReloadKeepsTheTexturesOfABadManifest(t *testing.T) {
+	manifest := func(color string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(`{"grass": {"top": {"path": "assets/textures/block/grass_block_top.png", "color": "` + color + `"}}}`)}
+	}
+	assets := fstest.MapFS{TEXTURE_MANIFEST: manifest("102,240,84")}
+	w := NewHeadlessWorld(0, assets)
+	defer w.Close()
+
+	for _, color := range []string{"1,2", "a,b,c", "1,2,256", "1,2,3,4"} {
+		assets[TEXTURE_MANIFEST] = manifest(color)
+		if err := w.ReloadTextures(); err == nil {
+			t.Errorf("reloading with the color %q succeeded", color)
+		}
+		if c := w.textures["grasstop"].Color; c == nil || *c != (Color{102, 240, 84}) {
+			t.Errorf("after reloading with the color %q the grass top is tinted %v", color, c)
+		}
+	}
+}
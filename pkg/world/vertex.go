@@ -0,0 +1,66 @@
+package world
+
+// Chunk vertices are packed into two uint32 words (8 bytes). The layout must
+// stay in sync with the unpacking done by the chunk vertex shader.
+//
+//	word 0: x (5 bits) | y (9 bits) | z (5 bits) | normal (3 bits) | u (1 bit) | v (1 bit)
+//	word 1: texture layer (8 bits) | ao (2 bits) | sky light (4 bits) | highlighted (1 bit) |
+//	        block light red, green and blue (4 bits each)
+const (
+	VERTEX_WORDS = 2
+	VERTEX_SIZE  = VERTEX_WORDS * 4
+
+	MAX_LIGHT = 15
+)
+
+type PackedVertex struct {
+	X, Y, Z     int
+	Normal      Direction
+	U, V        int
+	Layer       int
+	AO          int
+	Light       int
+	Highlighted bool
+	BlockLight  [3]int
+}
+
+func packVertex(v PackedVertex) (uint32, uint32) {
+	data := uint32(v.X)&31 |
+		(uint32(v.Y)&511)<<5 |
+		(uint32(v.Z)&31)<<14 |
+		(uint32(v.Normal)&7)<<19 |
+		(uint32(v.U)&1)<<22 |
+		(uint32(v.V)&1)<<23
+
+	attributes := uint32(v.Layer)&255 |
+		(uint32(v.AO)&3)<<8 |
+		(uint32(v.Light)&15)<<10 |
+		(uint32(v.BlockLight[0])&15)<<15 |
+		(uint32(v.BlockLight[1])&15)<<19 |
+		(uint32(v.BlockLight[2])&15)<<23
+	if v.Highlighted {
+		attributes |= 1 << 14
+	}
+
+	return data, attributes
+}
+
+func unpackVertex(data, attributes uint32) PackedVertex {
+	return PackedVertex{
+		X:           int(data & 31),
+		Y:           int(data >> 5 & 511),
+		Z:           int(data >> 14 & 31),
+		Normal:      Direction(data >> 19 & 7),
+		U:           int(data >> 22 & 1),
+		V:           int(data >> 23 & 1),
+		Layer:       int(attributes & 255),
+		AO:          int(attributes >> 8 & 3),
+		Light:       int(attributes >> 10 & 15),
+		Highlighted: attributes>>14&1 != 0,
+		BlockLight: [3]int{
+			int(attributes >> 15 & 15),
+			int(attributes >> 19 & 15),
+			int(attributes >> 23 & 15),
+		},
+	}
+}
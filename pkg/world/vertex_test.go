@@ -0,0 +1,19 @@
+package world
+
+import "testing"
+
+func TestPackVertexRoundTrip(t *testing.T) {
+	vertices := []PackedVertex{
+		{},
+		{X: 16, Y: WORLD_HEIGHT, Z: 16, Normal: Back, U: 1, V: 1, Layer: 255, AO: 3, Light: MAX_LIGHT, Highlighted: true},
+		{X: 3, Y: 71, Z: 12, Normal: Top, U: 0, V: 1, Layer: 2, AO: 1, Light: 7},
+		{Light: 3, BlockLight: [3]int{MAX_LIGHT, 9, 0}, Highlighted: true},
+	}
+
+	for _, v := range vertices {
+		data, attributes := packVertex(v)
+		if got := unpackVertex(data, attributes); got != v {
+			t.Errorf("round trip of %+v = %+v", v, got)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package world
+
+import (
+	"math"
+
+	minemath "github.com/wmattei/minceraft/math"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+// SectionVisibility records which faces of a section can see each other
+// through its non-opaque blocks. Bit from*6+to is set when a line of sight
+// entering through from can leave through to.
+type SectionVisibility uint64
+
+// ALL_VISIBLE connects every face to every other, as for an empty section.
+const ALL_VISIBLE SectionVisibility = 1<<36 - 1
+
+func (v SectionVisibility) Connects(from, to Direction) bool {
+	return v&(1<<(from*6+to)) != 0
+}
+
+func (v *SectionVisibility) connect(from, to Direction) {
+	*v |= 1<<(from*6+to) | 1<<(to*6+from)
+}
+
+// Opposite is the direction pointing the other way.
+func (d Direction) Opposite() Direction {
+	return d ^ 1
+}
+
+// visibility flood fills the non-opaque blocks of the section from its
+// borders and connects the faces every filled region touches. Sections meshed
+// at a lower level of detail are far enough to be left connected. The fill
+// uses the scratch space of the builder.
+func (s *SectionSnapshot) visibility(builder *MeshBuilder) SectionVisibility {
+	if s.LOD > 0 {
+		return ALL_VISIBLE
+	}
+
+	var visibility SectionVisibility
+	visited := &builder.visited
+	clear(visited[:])
+	queue := builder.queue[:0]
+
+	for x := 0; x < 16; x++ {
+		for y := 0; y < SECTION_SIZE; y++ {
+			for z := 0; z < 16; z++ {
+				if borderFaces(x, y, z) == 0 || visited[sectionLightIndex(x, y, z)] || s.isSolidAt(x, y, z) {
+					continue
+				}
+
+				var faces uint8
+				visited[sectionLightIndex(x, y, z)] = true
+				queue = append(queue[:0], [3]int{x, y, z})
+				for len(queue) > 0 {
+					block := queue[len(queue)-1]
+					queue = queue[:len(queue)-1]
+					faces |= borderFaces(block[0], block[1], block[2])
+
+					for _, offset := range directionOffsets {
+						nx, ny, nz := block[0]+offset[0], block[1]+offset[1], block[2]+offset[2]
+						if nx < 0 || nx >= 16 || ny < 0 || ny >= SECTION_SIZE || nz < 0 || nz >= 16 {
+							continue
+						}
+						index := sectionLightIndex(nx, ny, nz)
+						if visited[index] || s.isSolidAt(nx, ny, nz) {
+							continue
+						}
+						visited[index] = true
+						queue = append(queue, [3]int{nx, ny, nz})
+					}
+				}
+
+				for from := Right; from <= Back; from++ {
+					for to := from; to <= Back; to++ {
+						if faces&(1<<from) != 0 && faces&(1<<to) != 0 {
+							visibility.connect(from, to)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	builder.queue = queue
+	return visibility
+}
+
+// borderFaces is the set of faces of the section the block touches.
+func borderFaces(x, y, z int) uint8 {
+	var faces uint8
+	if x == 15 {
+		faces |= 1 << Right
+	}
+	if x == 0 {
+		faces |= 1 << Left
+	}
+	if y == SECTION_SIZE-1 {
+		faces |= 1 << Top
+	}
+	if y == 0 {
+		faces |= 1 << Bottom
+	}
+	if z == 15 {
+		faces |= 1 << Front
+	}
+	if z == 0 {
+		faces |= 1 << Back
+	}
+	return faces
+}
+
+type visibilityNode struct {
+	chunk   *Chunk
+	section int
+	// from is the face the walk entered the section through, -1 for the
+	// section of the camera.
+	from Direction
+	// directions holds every direction stepped in to reach the section.
+	directions uint8
+}
+
+// cullOccluded marks the sections hidden from the camera. It walks the
+// sections in the frustum from the one holding the camera, only crossing a
+// section between faces it connects and never stepping back towards the
+// camera.
+func (w *World) cullOccluded(camera minemath.Vec3, frustum *render.Frustum) {
+	for _, chunk := range w.chunks {
+		for _, section := range chunk.Sections {
+			section.Occluded = w.occlusionCulling
+		}
+	}
+	if !w.occlusionCulling {
+		return
+	}
+
+	chunkX, chunkZ, _, _ := worldToChunkCoords(int(math.Floor(float64(camera[0]))), int(math.Floor(float64(camera[2]))))
+	start, ok := w.chunks[[2]int{chunkX, chunkZ}]
+	section := int(math.Floor(float64(camera[1]))) / SECTION_SIZE
+	if !ok || camera[1] < 0 || section >= SECTIONS_PER_CHUNK {
+		// Outside of the loaded world, nothing is known to hide anything.
+		for _, chunk := range w.chunks {
+			for _, section := range chunk.Sections {
+				section.Occluded = false
+			}
+		}
+		return
+	}
+
+	if w.visibilityVisited == nil {
+		w.visibilityVisited = make(map[[3]int]bool)
+	}
+	visited := w.visibilityVisited
+	clear(visited)
+
+	queue := append(w.visibilityQueue[:0], visibilityNode{chunk: start, section: section, from: -1})
+	visited[[3]int{chunkX, section, chunkZ}] = true
+	start.Sections[section].Occluded = false
+
+	for i := 0; i < len(queue); i++ {
+		node := queue[i]
+		visibility := node.chunk.Sections[node.section].Visibility
+
+		for direction := Right; direction <= Back; direction++ {
+			if node.directions&(1<<direction.Opposite()) != 0 {
+				continue
+			}
+			if node.from >= 0 && !visibility.Connects(node.from, direction) {
+				continue
+			}
+
+			offset := directionOffsets[direction]
+			neighbor := node.chunk
+			if offset[0] != 0 || offset[2] != 0 {
+				neighbor = w.chunks[[2]int{node.chunk.Position[0] + offset[0], node.chunk.Position[1] + offset[2]}]
+			}
+			next := node.section + offset[1]
+			if neighbor == nil || next < 0 || next >= SECTIONS_PER_CHUNK {
+				continue
+			}
+
+			key := [3]int{neighbor.Position[0], next, neighbor.Position[1]}
+			if visited[key] {
+				continue
+			}
+			visited[key] = true
+			if frustum.TestAABB(neighbor.SectionBounds(next)) == render.Outside {
+				continue
+			}
+
+			neighbor.Sections[next].Occluded = false
+			queue = append(queue, visibilityNode{
+				chunk:      neighbor,
+				section:    next,
+				from:       direction.Opposite(),
+				directions: node.directions | 1<<direction,
+			})
+		}
+	}
+	w.visibilityQueue = queue
+}
@@ -0,0 +1,73 @@
+package world
+
+import (
+	"math"
+	"testing"
+
+	minemath "github.com/wmattei/minceraft/math"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+func TestSectionVisibilityFollowsOpenings(t *testing.T) {
+	s := &SectionSnapshot{blocks: make([]blockSnapshot, SNAPSHOT_SIZE*SNAPSHOT_SIZE*SNAPSHOT_SIZE)}
+	for y := 0; y < SECTION_SIZE; y++ {
+		for z := 0; z < 16; z++ {
+			s.at(8, y, z).Solid = true
+		}
+	}
+
+	visibility := s.visibility(&MeshBuilder{})
+	if visibility.Connects(Left, Right) || visibility.Connects(Right, Left) {
+		t.Error("a solid wall lets the view through")
+	}
+	if !visibility.Connects(Left, Top) || !visibility.Connects(Front, Right) {
+		t.Error("faces on the same side of the wall don't see each other")
+	}
+
+	s.at(8, 5, 5).Solid = false
+	if visibility := s.visibility(&MeshBuilder{}); !visibility.Connects(Left, Right) {
+		t.Error("a hole in the wall doesn't let the view through")
+	}
+}
+
+func TestCullOccludedStopsAtOpaqueSections(t *testing.T) {
+	world := &World{chunks: make(map[[2]int]*Chunk), occlusionCulling: true}
+	for x := 0; x < 3; x++ {
+		chunk := &Chunk{Position: [2]int{x, 0}, World: world}
+		for i := range chunk.Sections {
+			chunk.Sections[i] = &ChunkSection{Index: i, Visibility: ALL_VISIBLE}
+		}
+		world.chunks[chunk.Position] = chunk
+	}
+	wall := world.chunks[[2]int{1, 0}]
+	for _, section := range wall.Sections {
+		section.Visibility = 0
+	}
+
+	eye := minemath.Vec3{8, 8, 8}
+	view := minemath.LookAt(eye, minemath.Vec3{9, 8, 8}, minemath.Vec3{0, 1, 0})
+	frustum := render.NewFrustum(nil)
+	frustum.UpdateFrustum(minemath.MultiplyMatrices(minemath.GetPerspectiveProjectionMatrix(math.Pi/2, 1, 0.1, 100), view))
+
+	world.cullOccluded(eye, frustum)
+	if wall.Sections[0].Occluded {
+		t.Error("the wall itself is occluded")
+	}
+	for i, section := range world.chunks[[2]int{2, 0}].Sections {
+		if !section.Occluded {
+			t.Errorf("section %d behind the wall is visible", i)
+		}
+	}
+
+	wall.Sections[0].Visibility = ALL_VISIBLE
+	world.cullOccluded(eye, frustum)
+	if world.chunks[[2]int{2, 0}].Sections[0].Occluded {
+		t.Error("the section seen through the opening is occluded")
+	}
+
+	world.SetOcclusionCulling(false)
+	world.cullOccluded(eye, frustum)
+	if world.chunks[[2]int{2, 0}].Sections[5].Occluded {
+		t.Error("sections are occluded with occlusion culling disabled")
+	}
+}
@@ -0,0 +1,538 @@
+package world
+
+import (
+	"io/fs"
+	"math"
+	"runtime"
+	"sync"
+
+	minemath "github.com/wmattei/minceraft/math"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+type World struct {
+	chunks map[[2]int]*Chunk
+	// assets is the stack of resource packs textures are read from.
+	assets   fs.FS
+	textures map[string]Texture
+	// blockTextures holds the uploaded textures, nil for headless worlds,
+	// which have no textureUploader.
+	blockTextures   render.SpriteTable
+	textureUploader render.TextureUploader
+	atlasManifest   string
+	animations      []*TextureAnimation
+	animationTime   float64
+	// terrain is the material chunks are drawn with, and terrainTextures
+	// the textures it samples besides the block textures and the meshes.
+	terrain         *render.Material
+	terrainTextures []render.TextureBinding
+	// meshes holds the meshes of every section, on the GPU unless the world
+	// is headless, and shadowCaster draws them into the shadow map.
+	meshes       render.MeshUploader
+	shadowCaster *render.Material
+	culling      CullingStats
+	noise        Noise
+	time         WorldTime
+	lighting     LightingSettings
+	fog          FogSettings
+	highlight    Color
+	activeChunk  [2]int
+	renderDist   int
+
+	loadedChunks map[[2]int]struct{}
+	// lodChosen is set once the chunks got the level of detail of the
+	// active chunk, see chooseLODs.
+	lodChosen bool
+
+	smoothLighting   bool
+	occlusionCulling bool
+	// visibilityVisited and visibilityQueue are reused by cullOccluded.
+	visibilityVisited map[[3]int]bool
+	visibilityQueue   []visibilityNode
+
+	meshWorkers *MeshWorkerPool
+	meshVersion uint64
+}
+
+func (w *World) Update(camera *render.PerspectiveCamera) {
+	x, z := int(camera.Position[0]), int(camera.Position[2])
+
+	chunkX, chunkZ, _, _ := worldToChunkCoords(x, z)
+
+	moved := chunkX != w.activeChunk[0] || chunkZ != w.activeChunk[1]
+	if moved {
+		w.activeChunk = [2]int{chunkX, chunkZ}
+		w.LoadChunks()
+	}
+	if moved || !w.lodChosen {
+		w.chooseLODs()
+	}
+
+	w.scheduleMeshes()
+	w.meshWorkers.Drain(MESH_UPLOAD_BUDGET, w.uploadMesh)
+}
+
+func (w *World) scheduleMeshes() {
+	for _, chunk := range w.chunks {
+		for _, section := range chunk.Sections {
+			if !section.NeedsUpdate {
+				continue
+			}
+
+			w.meshVersion++
+			section.meshVersion = w.meshVersion
+
+			if section.IsEmpty() {
+				section.Delete(w.meshes)
+				section.Visibility = ALL_VISIBLE
+				section.NeedsUpdate = false
+				continue
+			}
+			if !w.meshWorkers.HasCapacity() {
+				return
+			}
+
+			w.meshWorkers.Submit(chunk.Snapshot(section.Index))
+			section.NeedsUpdate = false
+		}
+	}
+}
+
+// uploadMesh hands a finished mesh to its section, dropping meshes of chunks
+// that were unloaded or changed again since the snapshot was taken.
+func (w *World) uploadMesh(mesh *SectionMesh) {
+	defer mesh.Release()
+
+	chunk, ok := w.chunks[mesh.Position]
+	if !ok {
+		return
+	}
+	section := chunk.Sections[mesh.Section]
+	if section.meshVersion != mesh.Version {
+		return
+	}
+
+	section.Visibility = mesh.Visibility
+	origin, _ := chunk.Bounds()
+	section.UpdateBuffers(w.meshes, mesh, origin)
+}
+
+// AdvanceTime moves the time of day forward by dt seconds.
+func (w *World) AdvanceTime(dt float64) {
+	w.time.Advance(dt)
+}
+
+// SetDayLength sets how long a full day lasts, in seconds. A length of 0 stops
+// the time.
+func (w *World) SetDayLength(seconds float64) {
+	w.time.DayLength = seconds
+}
+
+// Time is the time of day of the world.
+func (w *World) Time() WorldTime {
+	return w.time
+}
+
+// SmoothLighting reports whether the light is interpolated across faces.
+func (w *World) SmoothLighting() bool {
+	return w.smoothLighting
+}
+
+// SetSmoothLighting turns smooth lighting on or off and remeshes the world.
+func (w *World) SetSmoothLighting(enabled bool) {
+	if w.smoothLighting == enabled {
+		return
+	}
+	w.smoothLighting = enabled
+	for _, chunk := range w.chunks {
+		chunk.MarkAllDirty()
+	}
+}
+
+// SetLightingSettings replaces the lighting model of the world.
+func (w *World) SetLightingSettings(settings LightingSettings) {
+	w.lighting = settings
+}
+
+// Lighting returns the lighting of the current time of day.
+func (w *World) Lighting() LightingUniforms {
+	return w.lighting.Uniforms(w.time)
+}
+
+// HighlightColor is the colour of highlighted blocks.
+func (w *World) HighlightColor() minemath.Vec3 {
+	highlight := w.highlight.ToVec4()
+	return minemath.Vec3{highlight[0], highlight[1], highlight[2]}
+}
+
+func (w *World) Close() {
+	w.meshWorkers.Stop()
+	w.meshes.Delete()
+}
+
+func (w *World) LoadChunks() {
+	newLoadedChunks := make(map[[2]int]struct{})
+	lastAddedChunks := make(map[[2]int]bool)
+	activeX, activeZ := w.activeChunk[0], w.activeChunk[1]
+	renderDist := w.renderDist
+
+	var wg sync.WaitGroup
+	mu := &sync.Mutex{}
+
+	for x := activeX - renderDist; x < activeX+renderDist; x++ {
+		for z := activeZ - renderDist; z < activeZ+renderDist; z++ {
+			wg.Add(1)
+			go func(x, z int) {
+				defer wg.Done()
+				chunkPos := [2]int{x, z}
+				mu.Lock()
+				newLoadedChunks[chunkPos] = struct{}{}
+				mu.Unlock()
+
+				if _, ok := w.chunks[chunkPos]; !ok {
+					chunk := NewChunk(w, x, z, 16)
+					chunk.World = w
+
+					mu.Lock()
+					w.chunks[chunkPos] = chunk
+					lastAddedChunks[chunkPos] = true
+					mu.Unlock()
+				}
+			}(x, z)
+		}
+	}
+
+	wg.Wait()
+
+	// Unload chunks that are no longer within the render distance
+	var unloaded []*Chunk
+	for pos := range w.loadedChunks {
+		if _, exists := newLoadedChunks[pos]; !exists {
+			w.chunks[pos].Delete()
+			unloaded = append(unloaded, w.chunks[pos])
+			delete(w.chunks, pos)
+			delete(w.loadedChunks, pos)
+		}
+	}
+	for _, chunk := range unloaded {
+		w.updateNeighborChunks(chunk, lastAddedChunks)
+	}
+
+	for pos := range lastAddedChunks {
+		chunk := w.chunks[pos]
+		w.LightChunk(chunk)
+		chunk.Initialize()
+		w.updateNeighborChunks(chunk, lastAddedChunks)
+	}
+
+	// Update loaded chunks
+	w.loadedChunks = newLoadedChunks
+}
+
+// updateNeighborChunks remeshes the already loaded neighbours of a chunk that
+// was added or removed, whose border faces and ambient occlusion depend on it.
+func (w *World) updateNeighborChunks(chunk *Chunk, lastAddedChunks map[[2]int]bool) {
+	for _, neighbor := range chunk.GetAllNeighbors() {
+		if neighbor != nil && !lastAddedChunks[neighbor.Position] {
+			neighbor.MarkAllDirty()
+		}
+	}
+}
+
+// CreateTerrainMaterial makes the material chunks are drawn with, from the
+// chunk program. bind sets its uniforms for the frame, see Fog and Lighting,
+// and textures are those it samples besides the block textures and the mesh
+// origins, like the shadow map.
+func (w *World) CreateTerrainMaterial(program render.Program, bind func(program render.Program), textures ...render.TextureBinding) *render.Material {
+	w.terrain = render.NewMaterial("terrain", program)
+	w.terrain.Bind = bind
+	w.terrainTextures = textures
+	w.updateTerrainTextures()
+	return w.terrain
+}
+
+// CreateShadowCasterMaterial makes the material the sections are drawn with
+// into the shadow map, see SubmitShadowCasters.
+func (w *World) CreateShadowCasterMaterial(program render.Program) *render.Material {
+	w.shadowCaster = render.NewMaterial("shadow caster", program, w.meshes.Bindings()...)
+	return w.shadowCaster
+}
+
+func (w *World) updateTerrainTextures() {
+	if w.terrain == nil || w.blockTextures == nil {
+		return
+	}
+	w.terrain.Textures = append(w.blockTextures.Bindings(), w.terrainTextures...)
+	w.terrain.Textures = append(w.terrain.Textures, w.meshes.Bindings()...)
+}
+
+// ReloadTextures reads the textures from the assets again, after the resource
+// packs changed. Textures keep their index, so blocks don't need to be
+// recreated, and textures new to the manifest are numbered after them. The
+// current textures are kept when the new ones fail to load.
+func (w *World) ReloadTextures() error {
+	textures, err := readTextureManifest(w.assets)
+	if err != nil {
+		return err
+	}
+	textures = keepTextureIndices(w.textures, textures)
+	if w.blockTextures == nil {
+		w.textures = textures
+		return nil
+	}
+
+	a, err := loadAtlas(w.assets, w.atlasManifest)
+	if err != nil {
+		return err
+	}
+	blockTextures, animations, err := uploadTextures(w.textureUploader, textures, a)
+	if err != nil {
+		return err
+	}
+
+	w.blockTextures.Delete()
+	w.textures, w.blockTextures, w.animations = textures, blockTextures, animations
+	w.updateTerrainTextures()
+	return nil
+}
+
+// SkyColors returns the colours of the sky of the current time of day, straight
+// up and at the horizon, or those of the water when the camera is in it.
+func (w *World) SkyColors(camera minemath.Vec3) (zenith, horizon minemath.Vec3) {
+	top, bottom := w.time.SkyColors()
+	if w.IsUnderwater(camera) {
+		top, bottom = w.fog.UnderwaterColor, w.fog.UnderwaterColor
+	}
+	z, h := top.ToVec4(), bottom.ToVec4()
+	return minemath.Vec3{z[0], z[1], z[2]}, minemath.Vec3{h[0], h[1], h[2]}
+}
+
+// SubmitShadowCasters queues the sections in the volume of a shadow cascade
+// with the shadow caster material. Sections hidden from the camera still cast
+// shadows, so only those outside of the cascade are skipped.
+func (w *World) SubmitShadowCasters(renderer render.Renderer, cascade *render.Frustum) {
+	for _, chunk := range w.chunks {
+		if cascade.TestAABB(chunk.Bounds()) == render.Outside {
+			continue
+		}
+		for i, section := range chunk.Sections {
+			if section.Geometry != nil && cascade.TestAABB(chunk.SectionBounds(i)) != render.Outside {
+				renderer.Submit(section.Geometry.DrawCall(w.shadowCaster))
+			}
+		}
+	}
+}
+
+// CullingStats counts the chunks and sections the last frame drew and those
+// it skipped for being out of view or hidden behind others.
+type CullingStats struct {
+	ChunksDrawn      int
+	ChunksCulled     int
+	SectionsDrawn    int
+	SectionsCulled   int
+	SectionsOccluded int
+}
+
+// OcclusionCulling reports whether sections hidden behind others are skipped.
+func (w *World) OcclusionCulling() bool {
+	return w.occlusionCulling
+}
+
+func (w *World) SetOcclusionCulling(enabled bool) {
+	w.occlusionCulling = enabled
+}
+
+func (w *World) CullingStats() CullingStats {
+	return w.culling
+}
+
+// Render draws the chunks in the frustum with the terrain material, see
+// CreateTerrainMaterial. Sections are only tested against the frustum when
+// their chunk crosses it.
+func (w *World) Render(renderer render.Renderer, frustum *render.Frustum, camera *render.PerspectiveCamera) {
+	w.culling = CullingStats{}
+	w.cullOccluded(*camera.Position, frustum)
+	for _, chunk := range w.chunks {
+		var sectionFrustum *render.Frustum
+		switch frustum.TestAABB(chunk.Bounds()) {
+		case render.Outside:
+			w.culling.ChunksCulled++
+			continue
+		case render.Intersecting:
+			sectionFrustum = frustum
+		}
+
+		drawn, culled, occluded := chunk.Submit(renderer, w.terrain, sectionFrustum)
+		w.culling.ChunksDrawn++
+		w.culling.SectionsDrawn += drawn
+		w.culling.SectionsCulled += culled
+		w.culling.SectionsOccluded += occluded
+	}
+	renderer.Flush()
+}
+
+func NewSingleBlockWorld(assets fs.FS, backend Backend, atlasManifest string) *World {
+	world := NewWorld(0, assets, backend, atlasManifest)
+
+	chunk := NewChunk(world, 0, 0, 1)
+	chunk.World = world
+	chunk.Initialize()
+
+	world.chunks[[2]int{0, 0}] = chunk
+
+	return world
+}
+
+func NewSingleChunkWorld(assets fs.FS, backend Backend, atlasManifest string) *World {
+	world := NewWorld(0, assets, backend, atlasManifest)
+
+	chunk := NewChunk(world, 0, 0, 16)
+	chunk.World = world
+	chunk.Initialize()
+	world.chunks[[2]int{0, 0}] = chunk
+
+	return world
+
+}
+
+// Backend is where a world stores what it draws: the meshes of its sections
+// and its block textures.
+type Backend struct {
+	Meshes   render.MeshUploader
+	Textures render.TextureUploader
+}
+
+// NewWorld generates a world whose textures are read from assets, a stack of
+// resource packs, and stitched into an atlas unless atlasManifest names one
+// written by cmd/atlas.
+func NewWorld(size int, assets fs.FS, backend Backend, atlasManifest string) *World {
+	world := newWorld(size, LoadTextureManifest(assets))
+	world.assets = assets
+	world.atlasManifest = atlasManifest
+	world.textureUploader = backend.Textures
+	world.blockTextures, world.animations = LoadTextures(backend.Textures, world.textures, LoadAtlas(assets, atlasManifest))
+	world.meshes = backend.Meshes
+	return world
+}
+
+// NewHeadlessWorld generates a world without a graphics backend. Its textures
+// are read from the manifest but never uploaded and its meshes are only
+// recorded, so it can be meshed, streamed and exported, and rendered to a
+// RecordingRenderer.
+func NewHeadlessWorld(size int, assets fs.FS) *World {
+	world := newWorld(size, LoadTextureManifest(assets))
+	world.assets = assets
+	return world
+}
+
+func newWorld(size int, textures map[string]Texture) *World {
+	world := &World{
+		chunks:       make(map[[2]int]*Chunk, size*size),
+		loadedChunks: make(map[[2]int]struct{}, size*size),
+		textures:     textures,
+		noise:        Noise{},
+		time:         NewWorldTime(DEFAULT_DAY_LENGTH),
+		lighting:     DefaultLightingSettings(),
+		fog:          DefaultFogSettings(),
+		highlight:    RED,
+		renderDist:   size,
+		meshWorkers:  NewMeshWorkerPool(runtime.NumCPU()-1, 64),
+		meshes:       render.NewRecordingMeshUploader(VERTEX_SIZE),
+		terrain:      render.NewMaterial("terrain", nil),
+
+		smoothLighting:   true,
+		occlusionCulling: true,
+	}
+
+	// centerChunk := NewChunk(world, 0, 0, 16)
+
+	for x := -size; x < size; x++ {
+		for z := -size; z < size; z++ {
+			c := NewChunk(world, x, z, 16)
+			world.chunks[[2]int{x, z}] = c
+			world.loadedChunks[[2]int{x, z}] = struct{}{}
+		}
+	}
+
+	for _, chunk := range world.chunks {
+		world.LightChunk(chunk)
+		chunk.Initialize()
+	}
+
+	return world
+
+}
+
+func (w *World) GetBlock(x, y, z int) *Block {
+	chunkX, chunkZ, posX, posZ := worldToChunkCoords(x, z)
+	activeChunk, ok := w.chunks[[2]int{chunkX, chunkZ}]
+	if !ok {
+		return nil
+	}
+	return activeChunk.At(posX, y, posZ)
+}
+
+// SetBlock replaces the block at the world position and schedules a rebuild of
+// every section whose mesh can see the change, including the ones across chunk
+// borders.
+func (w *World) SetBlock(x, y, z int, blockType BlockType) {
+	chunk, posX, posZ := w.chunkAt(x, z)
+	if chunk == nil || y < 0 || y >= WORLD_HEIGHT {
+		return
+	}
+
+	chunk.SetBlock(posX, y, posZ, blockType)
+	w.updateSkyLight(x, y, z)
+	w.updateBlockLight(x, y, z)
+
+	for dx := -1; dx <= 1; dx++ {
+		for dz := -1; dz <= 1; dz++ {
+			neighbor, _, _ := w.chunkAt(x+dx, z+dz)
+			if neighbor == nil {
+				continue
+			}
+			for dy := -1; dy <= 1; dy++ {
+				neighbor.MarkDirty(y + dy)
+			}
+		}
+	}
+}
+
+func (w *World) chunkAt(x, z int) (*Chunk, int, int) {
+	chunkX, chunkZ, posX, posZ := worldToChunkCoords(x, z)
+	return w.chunks[[2]int{chunkX, chunkZ}], posX, posZ
+}
+
+func (w *World) CheckCollisions(camera *render.PerspectiveCamera) {
+	pos := camera.Position
+	x, y, z := int(math.Floor(float64(pos[0]))), int(math.Floor(float64(pos[1]))), int(math.Floor(float64(pos[2])))
+
+	block := w.GetBlock(x, y-1, z)
+	if block != nil && block.IsSolid() {
+		block.Highlighted = true
+		block.Chunk.MarkDirty(y - 1)
+		camera.Position[1] = float32(y + 1)
+
+		return
+	}
+}
+
+func worldToChunkCoords(x, z int) (chunkX, chunkZ, posInChunkX, posInChunkZ int) {
+
+	chunkX = x / 16
+	chunkZ = z / 16
+
+	posInChunkX = x % 16
+	posInChunkZ = z % 16
+
+	if posInChunkX < 0 {
+		posInChunkX += 16
+		chunkX--
+	}
+	if posInChunkZ < 0 {
+		posInChunkZ += 16
+		chunkZ--
+	}
+
+	return chunkX, chunkZ, posInChunkX, posInChunkZ
+}
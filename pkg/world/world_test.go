@@ -0,0 +1,159 @@
+package world
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	minemath "github.com/wmattei/minceraft/math"
+	"github.com/wmattei/minceraft/pkg/render"
+)
+
+// meshAll updates the world until every section around the camera has a mesh.
+// Chunks at the edge of the world show their walls, so no section with blocks
+// meshes to nothing.
+func meshAll(t *testing.T, world *World, camera *render.PerspectiveCamera) {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		world.Update(camera)
+		if liveSections(world) == filledSections(world) {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("only %d of %d sections were meshed", liveSections(world), filledSections(world))
+}
+
+func liveSections(world *World) int {
+	live := 0
+	for _, chunk := range world.chunks {
+		for _, section := range chunk.Sections {
+			if section.Geometry != nil {
+				live++
+			}
+		}
+	}
+	return live
+}
+
+func filledSections(world *World) int {
+	filled := 0
+	for _, chunk := range world.chunks {
+		for _, section := range chunk.Sections {
+			if !section.IsEmpty() {
+				filled++
+			}
+		}
+	}
+	return filled
+}
+
+func TestHeadlessWorldStreamsAndRenders(t *testing.T) {
+	world := newExportTestWorld()
+	defer world.Close()
+	meshes := world.meshes.(*render.RecordingMeshUploader)
+
+	camera := render.NewPerspectiveCamera(minemath.Vec3{8, 100, 8}, minemath.Vec3{0, 1, 0}, 0, -45, math.Pi/2, 1, 0.1, 1000)
+	meshAll(t, world, camera)
+	if meshes.Live() != liveSections(world) {
+		t.Errorf("%d meshes are uploaded for %d sections", meshes.Live(), liveSections(world))
+	}
+
+	frustum := render.NewFrustum(camera)
+	frustum.UpdateFrustum(minemath.MultiplyMatrices(camera.GetProjectionMatrix(), camera.GetViewMatrix()))
+	renderer := render.NewRecordingRenderer()
+	world.Render(renderer, frustum, camera)
+	drawn := world.CullingStats().SectionsDrawn
+	if drawn == 0 || len(renderer.Flushed) != drawn {
+		t.Errorf("flushed %d draws for %d drawn sections", len(renderer.Flushed), drawn)
+	}
+	if stats := renderer.Stats(); stats.DrawCalls != 1 || stats.Meshes != drawn {
+		t.Errorf("drew %d meshes in %d draw calls, want one draw call", stats.Meshes, stats.DrawCalls)
+	}
+
+	// Walking away unloads every chunk, their meshes are freed.
+	*camera.Position = minemath.Vec3{10*16 + 8, 100, 8}
+	meshAll(t, world, camera)
+	if _, ok := world.chunks[[2]int{0, 0}]; ok {
+		t.Fatal("the chunk left behind is still loaded")
+	}
+	if meshes.Frees == 0 || meshes.Live() != liveSections(world) {
+		t.Errorf("%d meshes are uploaded for %d sections after %d frees", meshes.Live(), liveSections(world), meshes.Frees)
+	}
+}
+
+func TestLODIsChosenOnUpdate(t *testing.T) {
+	world := newExportTestWorld()
+	defer world.Close()
+	world.chunks[[2]int{12, 0}] = NewChunk(world, 12, 0, 16)
+
+	camera := render.NewPerspectiveCamera(minemath.Vec3{8, 100, 8}, minemath.Vec3{0, 1, 0}, 0, -45, math.Pi/2, 1, 0.1, 1000)
+	world.Update(camera)
+	if lod := world.chunks[[2]int{12, 0}].LOD; lod != MAX_LOD {
+		t.Errorf("the far chunk has LOD %d, want %d", lod, MAX_LOD)
+	}
+
+	for _, chunk := range world.chunks {
+		for _, section := range chunk.Sections {
+			section.NeedsUpdate = false
+		}
+	}
+	frustum := render.NewFrustum(camera)
+	frustum.UpdateFrustum(minemath.MultiplyMatrices(camera.GetProjectionMatrix(), camera.GetViewMatrix()))
+	*camera.Position = minemath.Vec3{15, 100, 15}
+	world.Render(render.NewRecordingRenderer(), frustum, camera)
+	world.Update(camera)
+	for pos, chunk := range world.chunks {
+		for _, section := range chunk.Sections {
+			if section.NeedsUpdate {
+				t.Fatalf("moving within the chunk remeshed section %d of chunk %v", section.Index, pos)
+			}
+		}
+	}
+}
+
+func TestUnloadingRemeshesTheNewEdge(t *testing.T) {
+	world := newWorld(2, map[string]Texture{})
+	defer world.Close()
+	for _, chunk := range world.chunks {
+		for _, section := range chunk.Sections {
+			section.NeedsUpdate = false
+		}
+	}
+
+	world.activeChunk = [2]int{1, 0}
+	world.LoadChunks()
+	if _, ok := world.chunks[[2]int{-2, 0}]; ok {
+		t.Fatal("the chunk left behind is still loaded")
+	}
+	for _, section := range world.chunks[[2]int{-1, 0}].Sections {
+		if !section.NeedsUpdate {
+			t.Fatalf("section %d of the new edge wasn't remeshed", section.Index)
+		}
+	}
+}
+
+func TestShadowCastersAreCulledToTheCascade(t *testing.T) {
+	world := &World{chunks: make(map[[2]int]*Chunk)}
+	for x := 0; x < 3; x++ {
+		chunk := &Chunk{Position: [2]int{x, 0}, World: world}
+		for i := range chunk.Sections {
+			chunk.Sections[i] = &ChunkSection{Index: i, filledBlocks: 1, Geometry: &render.Allocation{VAO: 1, IndexCount: 6}}
+		}
+		world.chunks[chunk.Position] = chunk
+	}
+	world.shadowCaster = render.NewMaterial("shadow caster", nil)
+
+	// A light straight above the first chunk, covering its lowest section.
+	view := minemath.LookAt(minemath.Vec3{8, 100, 8}, minemath.Vec3{8, 0, 8}, minemath.Vec3{0, 0, 1})
+	projection := minemath.GetOrthographicProjectionMatrix(-4, 4, -4, 4, 90, 95)
+	var cascade render.Frustum
+	cascade.UpdateFrustum(minemath.MultiplyMatrices(projection, view))
+
+	renderer := render.NewRecordingRenderer()
+	world.SubmitShadowCasters(renderer, &cascade)
+	renderer.Flush()
+	if len(renderer.Flushed) != 1 {
+		t.Errorf("drew %d shadow casters, want the section in the cascade only", len(renderer.Flushed))
+	}
+}
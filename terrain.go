@@ -0,0 +1,89 @@
+package main
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/wmattei/minceraft/pkg/engine"
+	"github.com/wmattei/minceraft/pkg/render"
+	"github.com/wmattei/minceraft/pkg/world"
+)
+
+// newGLBackend stores the meshes of a world in a geometry pool, in the packed
+// vertex format of the chunk program, and its textures in a texture array.
+func newGLBackend() world.Backend {
+	return world.Backend{
+		Meshes: engine.NewGeometryPool(world.VERTEX_SIZE, func() {
+			gl.VertexAttribIPointer(0, world.VERTEX_WORDS, gl.UNSIGNED_INT, world.VERTEX_SIZE, gl.PtrOffset(0))
+			gl.EnableVertexAttribArray(0)
+		}),
+		Textures: engine.GLTextureUploader{},
+	}
+}
+
+// createTerrainMaterials makes the materials the world is drawn with, from
+// the chunk program, and into the shadow map. The uniforms of the terrain
+// follow the camera, the time of day and the shadow cascades of the frame.
+func createTerrainMaterials(w *world.World, program *engine.ShaderProgram, shadows *engine.ShadowMap, camera *render.PerspectiveCamera) {
+	w.CreateShadowCasterMaterial(shadows.Program)
+	w.CreateTerrainMaterial(program, func(program render.Program) {
+		view := camera.GetViewMatrix()
+		viewFlatten := view.Flatten()
+		projection := camera.GetProjectionMatrix()
+		projectionFlatten := projection.Flatten()
+		gl.UniformMatrix4fv(program.Uniform("view"), 1, false, &viewFlatten[0])
+		gl.UniformMatrix4fv(program.Uniform("projection"), 1, false, &projectionFlatten[0])
+
+		shadows.Bind(program)
+		bindLighting(program, w.Lighting())
+		bindFog(program, w.Fog(*camera.Position))
+		highlight := w.HighlightColor()
+		gl.Uniform3f(program.Uniform("highlightColor"), highlight[0], highlight[1], highlight[2])
+	}, shadows.Binding())
+}
+
+// bindLighting uploads the lighting to the uniforms of the chunk program.
+func bindLighting(program render.Program, lighting world.LightingUniforms) {
+	uniform := program.Uniform
+
+	gl.Uniform3f(uniform("ambientLight"), lighting.Ambient[0], lighting.Ambient[1], lighting.Ambient[2])
+	gl.Uniform3f(uniform("hemisphereSky"), lighting.HemisphereSky[0], lighting.HemisphereSky[1], lighting.HemisphereSky[2])
+	gl.Uniform3f(uniform("hemisphereGround"), lighting.HemisphereGround[0], lighting.HemisphereGround[1], lighting.HemisphereGround[2])
+
+	gl.Uniform1i(uniform("lightCount"), int32(lighting.LightCount))
+	gl.Uniform3fv(uniform("lightDirections"), world.MAX_DIRECTIONAL_LIGHTS, &lighting.LightDirections[0])
+	gl.Uniform3fv(uniform("lightColors"), world.MAX_DIRECTIONAL_LIGHTS, &lighting.LightColors[0])
+	gl.Uniform1fv(uniform("faceShading"), 6, &lighting.FaceShading[0])
+
+	gl.Uniform1f(uniform("skyBrightness"), lighting.SkyBrightness)
+}
+
+// bindFog uploads the fog of the frame to the chunk program.
+func bindFog(program render.Program, fog world.FogUniforms) {
+	uniform := program.Uniform
+
+	gl.Uniform1i(uniform("fogMode"), int32(fog.Mode))
+	gl.Uniform1f(uniform("fogStart"), fog.Start)
+	gl.Uniform1f(uniform("fogEnd"), fog.End)
+	gl.Uniform1f(uniform("fogDensity"), fog.Density)
+	gl.Uniform3f(uniform("fogColor"), fog.Color[0], fog.Color[1], fog.Color[2])
+}
+
+// renderSky clears the screen to the sky of the world, see World.SkyColors.
+func renderSky(w *world.World, sky *engine.Sky, camera *render.PerspectiveCamera) {
+	zenith, horizon := w.SkyColors(*camera.Position)
+	sky.Render(camera, zenith, horizon, w.Time().SunDirection())
+}
+
+// renderShadows fits the shadow cascades to the camera and renders the depth
+// of the world, as seen from the sun or the moon, into them. cascade holds
+// the volume of the cascade being drawn.
+func renderShadows(w *world.World, renderer render.Renderer, shadows *engine.ShadowMap, camera *render.PerspectiveCamera, cascade *render.Frustum) {
+	shadows.Fit(camera, w.Time().LightDirection())
+
+	for i, c := range shadows.Cascades {
+		shadows.BeginCascade(i)
+		cascade.UpdateFrustum(c.ViewProjection)
+		w.SubmitShadowCasters(renderer, cascade)
+		renderer.Flush()
+	}
+	shadows.End()
+}